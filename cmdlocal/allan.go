@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+
+	"github.com/viam-modules/tdk-invensense/mpu6050"
+)
+
+var allanAxes = []string{"accel_x", "accel_y", "accel_z", "gyro_x", "gyro_y", "gyro_z"}
+
+// runAllan records the sensor sitting still, or loads a recording made with "read -format csv",
+// and prints its Allan deviation curve and noise parameters.
+func runAllan(ctx context.Context, logger logging.Logger, opts *options) error {
+	var samples []mpu6050.InertialSample
+	rate := opts.rate
+	if opts.in != "" {
+		recording, err := readRecording(opts.in)
+		if err != nil {
+			return err
+		}
+		if len(recording) < 2 {
+			return errors.New("the recording has too few samples")
+		}
+		// The recording was made at a fixed rate, which we recover from its timestamps.
+		elapsed := recording[len(recording)-1].Time.Sub(recording[0].Time).Seconds()
+		if elapsed <= 0 {
+			return errors.New("the recording's timestamps don't advance")
+		}
+		rate = float64(len(recording)-1) / elapsed
+		for _, s := range recording {
+			samples = append(samples, inertialSample(s))
+		}
+	} else {
+		if opts.duration <= 0 {
+			return errors.New("allan needs a positive duration to record")
+		}
+		fmt.Fprintf(os.Stderr, "keep the sensor still for %s...\n", opts.duration)
+		err := collectSamples(ctx, logger, opts, func(s sample) error {
+			samples = append(samples, inertialSample(s))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := mpu6050.AllanDeviation(samples, rate)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(opts)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch opts.format {
+	case "table":
+		writeAllanCurveTable(out, result)
+		fmt.Fprintln(out)
+		writeAllanParameters(out, result)
+		return nil
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case "csv":
+		// The CSV holds only the curve, so that it loads cleanly into a plotting tool; the parameters
+		// go to stderr.
+		writeAllanParameters(os.Stderr, result)
+		return writeAllanCurveCSV(out, result)
+	default:
+		return errors.Errorf("unknown format %q", opts.format)
+	}
+}
+
+func inertialSample(s sample) mpu6050.InertialSample {
+	return mpu6050.InertialSample{
+		Accel: [3]float64{s.LinearAcceleration.X, s.LinearAcceleration.Y, s.LinearAcceleration.Z},
+		Gyro:  [3]float64{s.AngularVelocity.X, s.AngularVelocity.Y, s.AngularVelocity.Z},
+	}
+}
+
+func allanRow(point mpu6050.AllanPoint) []float64 {
+	return []float64{point.Accel[0], point.Accel[1], point.Accel[2], point.Gyro[0], point.Gyro[1], point.Gyro[2]}
+}
+
+func writeAllanCurveTable(w io.Writer, result *mpu6050.AllanResult) {
+	fmt.Fprintf(w, "%12s", "tau_sec")
+	for _, axis := range allanAxes {
+		fmt.Fprintf(w, " %12s", axis)
+	}
+	fmt.Fprintln(w)
+	for _, point := range result.Curve {
+		fmt.Fprintf(w, "%12.4g", point.Tau)
+		for _, deviation := range allanRow(point) {
+			fmt.Fprintf(w, " %12.4g", deviation)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeAllanCurveCSV(w io.Writer, result *mpu6050.AllanResult) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"tau_sec"}, allanAxes...)); err != nil {
+		return err
+	}
+	for _, point := range result.Curve {
+		record := []string{strconv.FormatFloat(point.Tau, 'g', -1, 64)}
+		for _, deviation := range allanRow(point) {
+			record = append(record, strconv.FormatFloat(deviation, 'g', -1, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeAllanParameters(w io.Writer, result *mpu6050.AllanResult) {
+	fmt.Fprintf(w, "%d samples at %.1f Hz\n", result.Samples, result.SampleRate)
+	fmt.Fprintf(w, "%-8s %22s %24s %10s\n", "axis", "random walk", "bias instability", "at tau")
+	printAxes := func(sensor string, params [3]mpu6050.NoiseParameters, walkUnit, biasUnit string) {
+		for i, p := range params {
+			fmt.Fprintf(w, "%-8s %12.4g %-9s %12.4g %-11s %9.4gs\n",
+				sensor+"_"+string(rune('x'+i)), p.RandomWalk, walkUnit, p.BiasInstability, biasUnit, p.BiasInstabilityTau)
+		}
+	}
+	printAxes("accel", result.Accel, "m/s/rt-h", "m/s^2")
+	printAxes("gyro", result.Gyro, "deg/rt-h", "deg/h")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+
+	"github.com/viam-modules/tdk-invensense/mpu6050"
+)
+
+const gravity = 9.81 // m/sec/sec
+
+// runCalibrate averages the sensor output while it sits still and level, and prints the resulting
+// biases as a config snippet.
+func runCalibrate(ctx context.Context, logger logging.Logger, opts *options) error {
+	if opts.duration <= 0 {
+		return errors.New("calibrate needs a positive duration")
+	}
+	fmt.Fprintf(os.Stderr, "keep the sensor still for %s...\n", opts.duration)
+
+	var accelSum, gyroSum r3.Vector
+	count := 0
+	err := collectSamples(ctx, logger, opts, func(s sample) error {
+		accelSum = accelSum.Add(s.LinearAcceleration)
+		gyroSum = gyroSum.Add(r3.Vector{X: s.AngularVelocity.X, Y: s.AngularVelocity.Y, Z: s.AngularVelocity.Z})
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("no samples collected")
+	}
+
+	accelMean := accelSum.Mul(1 / float64(count))
+	gyroMean := gyroSum.Mul(1 / float64(count))
+
+	// Whichever axis sees the most acceleration is the one pointing up: gravity is expected there and
+	// is not part of the bias.
+	accelBias := accelMean
+	switch accelMean.Abs().LargestComponent() {
+	case r3.XAxis:
+		accelBias.X -= math.Copysign(gravity, accelMean.X)
+	case r3.YAxis:
+		accelBias.Y -= math.Copysign(gravity, accelMean.Y)
+	case r3.ZAxis:
+		accelBias.Z -= math.Copysign(gravity, accelMean.Z)
+	}
+
+	return writeSnippet(opts, "calibration", mpu6050.Calibration{
+		GyroBias:  []float64{gyroMean.X, gyroMean.Y, gyroMean.Z},
+		AccelBias: []float64{accelBias.X, accelBias.Y, accelBias.Z},
+	})
+}
+
+// runTempCalibrate fits the gyroscope bias against the die temperature, either from a recording
+// made with "read -format csv" or by recording live while the temperature changes.
+func runTempCalibrate(ctx context.Context, logger logging.Logger, opts *options) error {
+	var samples []mpu6050.TemperatureSample
+	if opts.in != "" {
+		var err error
+		if samples, err = readTemperatureSweep(opts.in); err != nil {
+			return err
+		}
+	} else {
+		if opts.duration <= 0 {
+			return errors.New("temp-calibrate needs a positive duration to record a sweep")
+		}
+		fmt.Fprintf(os.Stderr, "keep the sensor still while its temperature changes for %s...\n", opts.duration)
+		err := collectSamples(ctx, logger, opts, func(s sample) error {
+			samples = append(samples, mpu6050.TemperatureSample{
+				Celsius: s.Temperature,
+				Gyro:    [3]float64{s.AngularVelocity.X, s.AngularVelocity.Y, s.AngularVelocity.Z},
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	compensation, err := mpu6050.FitTemperatureCompensation(samples, opts.degree)
+	if err != nil {
+		return err
+	}
+	return writeSnippet(opts, "temperature_compensation", compensation)
+}
+
+// readTemperatureSweep loads the gyroscope and temperature columns of a CSV written by "read".
+func readTemperatureSweep(path string) ([]mpu6050.TemperatureSample, error) {
+	recording, err := readRecording(path)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]mpu6050.TemperatureSample, 0, len(recording))
+	for _, s := range recording {
+		samples = append(samples, mpu6050.TemperatureSample{
+			Celsius: s.Temperature,
+			Gyro:    [3]float64{s.AngularVelocity.X, s.AngularVelocity.Y, s.AngularVelocity.Z},
+		})
+	}
+	return samples, nil
+}
+
+// writeSnippet prints a config attribute as JSON, ready to paste into the component's attributes.
+func writeSnippet(opts *options, attribute string, value interface{}) error {
+	data, err := json.MarshalIndent(map[string]interface{}{attribute: value}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(opts)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
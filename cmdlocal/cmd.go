@@ -1,43 +1,195 @@
-// Package main for testing mpu6050 locally
+// Package main is a command line tool for testing and diagnosing an mpu6050 attached to the local
+// machine.
 package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
 	"time"
-	
+
+	"github.com/pkg/errors"
 	"go.viam.com/rdk/logging"
 
 	"github.com/viam-modules/tdk-invensense/mpu6050"
 )
 
+const usage = `usage: cmdlocal <command> [flags]
+
+commands:
+  scan             look for MPU devices on the bus
+  read             stream readings at a fixed rate
+  dump-registers   print the contents of every register
+  self-test        run the chip's built-in self-test
+  calibrate        measure sensor biases and print a config snippet
+  temp-calibrate   fit gyroscope bias against temperature and print a config snippet
+  allan            characterize sensor noise with the Allan deviation
+
+run "cmdlocal <command> -h" to see the flags for a command.
+`
+
+// options holds the flags shared by every command.
+type options struct {
+	bus        string
+	alt        bool
+	gyroRange  int
+	accelRange int
+	duration   time.Duration
+	rate       float64
+	format     string
+	out        string
+	in         string
+	degree     int
+}
+
+func (opts *options) config() *mpu6050.Config {
+	return &mpu6050.Config{
+		I2cBus:                 opts.bus,
+		UseAlternateI2CAddress: opts.alt,
+		GyroRange:              opts.gyroRange,
+		AccelRange:             opts.accelRange,
+	}
+}
+
+func (opts *options) register(fs *flag.FlagSet, defaultDuration time.Duration, defaultRate float64) {
+	fs.StringVar(&opts.bus, "bus", "1", "I2C bus the chip is wired to")
+	fs.BoolVar(&opts.alt, "alt", false, "use the alternate I2C address (0x69)")
+	fs.IntVar(&opts.gyroRange, "gyro-range", 250, "gyroscope range in degrees per second: 250, 500, 1000, or 2000")
+	fs.IntVar(&opts.accelRange, "accel-range", 2, "accelerometer range in g: 2, 4, 8, or 16")
+	fs.DurationVar(&opts.duration, "duration", defaultDuration, "how long to run; 0 runs until interrupted")
+	fs.Float64Var(&opts.rate, "rate", defaultRate, "samples per second")
+	fs.StringVar(&opts.format, "format", "table", "output format: table, json, or csv")
+	fs.StringVar(&opts.out, "out", "", "file to write output to instead of stdout")
+	fs.StringVar(&opts.in, "in", "", "temp-calibrate and allan: CSV recording from \"read -format csv\" to analyze instead of recording live")
+	fs.IntVar(&opts.degree, "degree", 2, "temp-calibrate: degree of the polynomial fit")
+}
+
 func main() {
-	err := realMain()
-	if err != nil {
-		panic(err)
+	if err := realMain(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
 
-func realMain() error {
-	ctx := context.Background()
+func realMain(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("no command given")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 	logger := logging.NewLogger("mpu6050-local")
 
-	ms, err := mpu6050.NewMpu6050(ctx, logger, "foo", "1", false)
+	command := args[0]
+	var run func(context.Context, logging.Logger, *options) error
+	defaultDuration := time.Duration(0)
+	defaultRate := 10.0
+	switch command {
+	case "scan":
+		run = runScan
+	case "read":
+		run = runRead
+	case "dump-registers":
+		run = runDumpRegisters
+	case "self-test":
+		run = runSelfTest
+	case "calibrate":
+		run = runCalibrate
+		defaultDuration = 10 * time.Second
+	case "temp-calibrate":
+		run = runTempCalibrate
+		defaultDuration = 30 * time.Minute
+	case "allan":
+		run = runAllan
+		defaultDuration = 2 * time.Hour
+		defaultRate = 100
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return errors.Errorf("unknown command %q", command)
+	}
+
+	opts := &options{}
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	opts.register(fs, defaultDuration, defaultRate)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if opts.rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	return run(ctx, logger, opts)
+}
+
+func runScan(ctx context.Context, logger logging.Logger, opts *options) error {
+	results, err := mpu6050.Scan(ctx, logger, opts.bus)
 	if err != nil {
 		return err
 	}
+	if len(results) == 0 {
+		fmt.Printf("no MPU devices found on bus %s\n", opts.bus)
+		return nil
+	}
+	for _, result := range results {
+		model := result.Model
+		if model == "" {
+			model = "unknown device"
+		}
+		fmt.Printf("bus %s address %#x: WHO_AM_I %#x (%s)\n", opts.bus, result.Address, result.WhoAmI, model)
+	}
+	return nil
+}
 
-	for range 30 {
-		av, err := ms.AngularVelocity(ctx, nil)
-		if err != nil {
-			return err
+func runDumpRegisters(ctx context.Context, logger logging.Logger, opts *options) error {
+	registers, err := mpu6050.DumpRegisters(ctx, logger, opts.config())
+	if err != nil {
+		return err
+	}
+	for register, value := range registers {
+		name, fields := mpu6050.DecodeRegister(byte(register), value)
+		if mpu6050.ConsumedOnRead(byte(register)) {
+			// Reading it would take data away from anything sampling the chip.
+			fmt.Printf("%#04x (%3d) %-18s not read\n", register, register, name)
+			continue
 		}
-		la, err := ms.LinearAcceleration(ctx, nil)
-		if err != nil {
-			return err
+		fmt.Printf("%#04x (%3d) %-18s %#04x  %08b", register, register, name, value, value)
+		keys := make([]string, 0, len(fields))
+		for field := range fields {
+			keys = append(keys, field)
 		}
+		sort.Strings(keys)
+		for _, field := range keys {
+			fmt.Printf("  %s=%d", strings.TrimPrefix(field, name+"."), fields[field])
+		}
+		fmt.Println()
+	}
+	return nil
+}
 
-		logger.Infof("angular velocity: %0.2f %0.2f %0.2f linear acceleration: %0.2f %0.2f %0.2f", av.X, av.Y, av.Z, la.X, la.Y, la.Z)
-		time.Sleep(time.Second)
+func runSelfTest(ctx context.Context, logger logging.Logger, opts *options) error {
+	result, err := mpu6050.SelfTest(ctx, logger, opts.config())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-8s %-4s %12s %12s %10s  %s\n", "sensor", "axis", "factory trim", "response", "deviation", "result")
+	printAxes := func(sensor string, axes [3]mpu6050.SelfTestAxis) {
+		for i, axis := range axes {
+			status := "FAIL"
+			if axis.Passed {
+				status = "pass"
+			}
+			fmt.Printf("%-8s %-4s %12.1f %12.1f %9.1f%%  %s\n",
+				sensor, string(rune('x'+i)), axis.FactoryTrim, axis.Response, axis.DeviationPercent, status)
+		}
+	}
+	printAxes("gyro", result.Gyro)
+	printAxes("accel", result.Accel)
+	if !result.Passed() {
+		return errors.New("self-test failed")
 	}
 	return nil
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/spatialmath"
+
+	"github.com/viam-modules/tdk-invensense/mpu6050"
+)
+
+// sample is a single set of readings taken from the sensor.
+type sample struct {
+	Time               time.Time                   `json:"time"`
+	LinearAcceleration r3.Vector                   `json:"linear_acceleration"`
+	AngularVelocity    spatialmath.AngularVelocity `json:"angular_velocity"`
+	Temperature        float64                     `json:"temperature_celsius"`
+}
+
+func (s sample) fields() []float64 {
+	return []float64{
+		s.LinearAcceleration.X, s.LinearAcceleration.Y, s.LinearAcceleration.Z,
+		s.AngularVelocity.X, s.AngularVelocity.Y, s.AngularVelocity.Z,
+		s.Temperature,
+	}
+}
+
+var sampleHeader = []string{"time", "accel_x", "accel_y", "accel_z", "gyro_x", "gyro_y", "gyro_z", "temp_c"}
+
+// sampleWriter formats samples in one of the supported output formats.
+type sampleWriter interface {
+	write(s sample) error
+	flush() error
+}
+
+func newSampleWriter(format string, w io.Writer) (sampleWriter, error) {
+	switch format {
+	case "table":
+		fmt.Fprintf(w, "%-15s %9s %9s %9s %9s %9s %9s %7s\n",
+			sampleHeader[0], sampleHeader[1], sampleHeader[2], sampleHeader[3],
+			sampleHeader[4], sampleHeader[5], sampleHeader[6], sampleHeader[7])
+		return &tableWriter{w: w}, nil
+	case "json":
+		return &jsonWriter{encoder: json.NewEncoder(w)}, nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(sampleHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: writer}, nil
+	default:
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+}
+
+type tableWriter struct {
+	w io.Writer
+}
+
+func (tw *tableWriter) write(s sample) error {
+	f := s.fields()
+	_, err := fmt.Fprintf(tw.w, "%-15s %9.3f %9.3f %9.3f %9.3f %9.3f %9.3f %7.2f\n",
+		s.Time.Format("15:04:05.000000"), f[0], f[1], f[2], f[3], f[4], f[5], f[6])
+	return err
+}
+
+func (tw *tableWriter) flush() error {
+	return nil
+}
+
+type jsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (jw *jsonWriter) write(s sample) error {
+	return jw.encoder.Encode(s)
+}
+
+func (jw *jsonWriter) flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) write(s sample) error {
+	record := []string{s.Time.Format(time.RFC3339Nano)}
+	for _, value := range s.fields() {
+		record = append(record, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	// Flush every record so that the output can be streamed.
+	return cw.flush()
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// openOutput returns the file named by -out, or stdout if there isn't one.
+func openOutput(opts *options) (io.WriteCloser, error) {
+	if opts.out == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(opts.out)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func readSample(ctx context.Context, ms movementsensor.MovementSensor) (sample, error) {
+	readings, err := ms.Readings(ctx, nil)
+	if err != nil {
+		return sample{}, err
+	}
+	return sample{
+		Time:               time.Now(),
+		LinearAcceleration: readings["linear_acceleration"].(r3.Vector),
+		AngularVelocity:    readings["angular_velocity"].(spatialmath.AngularVelocity),
+		Temperature:        readings["temperature_celsius"].(float64),
+	}, nil
+}
+
+// collectSamples calls handle once per sampling period until the duration runs out or the context
+// is cancelled.
+func collectSamples(
+	ctx context.Context,
+	logger logging.Logger,
+	opts *options,
+	handle func(sample) error,
+) error {
+	ms, err := mpu6050.NewMpu6050FromConfig(ctx, logger, "mpu6050", opts.config())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := ms.Close(context.Background()); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s, err := readSample(ctx, ms)
+			if err != nil {
+				return err
+			}
+			if err := handle(s); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func runRead(ctx context.Context, logger logging.Logger, opts *options) error {
+	out, err := openOutput(opts)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	writer, err := newSampleWriter(opts.format, out)
+	if err != nil {
+		return err
+	}
+	if err := collectSamples(ctx, logger, opts, writer.write); err != nil {
+		return err
+	}
+	return writer.flush()
+}
+
+// readRecording loads a CSV written by "read -format csv".
+func readRecording(path string) ([]sample, error) {
+	//nolint:gosec
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	if _, err := reader.Read(); err != nil {
+		return nil, errors.Wrap(err, "unable to read CSV header")
+	}
+	var samples []sample
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) != len(sampleHeader) {
+			return nil, errors.Errorf("expected %d columns, got %d", len(sampleHeader), len(record))
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, record[0])
+		if err != nil {
+			return nil, err
+		}
+		var values [7]float64
+		for i, column := range record[1:] {
+			if values[i], err = strconv.ParseFloat(column, 64); err != nil {
+				return nil, err
+			}
+		}
+		samples = append(samples, sample{
+			Time:               timestamp,
+			LinearAcceleration: r3.Vector{X: values[0], Y: values[1], Z: values[2]},
+			AngularVelocity:    spatialmath.AngularVelocity{X: values[3], Y: values[4], Z: values[5]},
+			Temperature:        values[6],
+		})
+	}
+}
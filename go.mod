@@ -15,6 +15,7 @@ require (
 	go.viam.com/rdk v0.63.0
 	go.viam.com/test v1.2.4
 	go.viam.com/utils v0.1.130
+	gonum.org/v1/gonum v0.12.0
 	gotest.tools/gotestsum v1.10.0
 )
 
@@ -327,7 +328,6 @@ require (
 	golang.org/x/time v0.6.0 // indirect
 	golang.org/x/tools v0.24.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
-	gonum.org/v1/gonum v0.12.0 // indirect
 	gonum.org/v1/plot v0.12.0 // indirect
 	google.golang.org/api v0.196.0 // indirect
 	google.golang.org/genproto v0.0.0-20240903143218-8af14fe29dc1 // indirect
@@ -7,6 +7,7 @@ import (
 	"github.com/viam-modules/tdk-invensense/mpu6050"
 
 	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/components/sensor"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/module"
 	"go.viam.com/utils"
@@ -25,6 +26,9 @@ func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) err
 	if err = module.AddModelFromRegistry(ctx, movementsensor.API, mpu6050.Model); err != nil {
 		return err
 	}
+	if err = module.AddModelFromRegistry(ctx, sensor.API, mpu6050.TemperatureModel); err != nil {
+		return err
+	}
 
 	err = module.Start(ctx)
 	defer module.Close(ctx)
@@ -0,0 +1,178 @@
+package mpu6050
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Averaging times are spaced logarithmically with this many per decade.
+	allanPointsPerDecade = 10
+	// The bias instability is the flat bottom of the Allan deviation curve divided by this factor,
+	// sqrt(2 ln 2 / pi).
+	biasInstabilityFactor = 0.664
+	// The random walk coefficient is read off the part of the curve with slope -1/2. Points whose
+	// slope is further than this from -1/2 aren't on it.
+	randomWalkSlopeTolerance = 0.1
+)
+
+// InertialSample is one accelerometer and gyroscope reading, used to characterize sensor noise.
+type InertialSample struct {
+	// Accel is the acceleration in m/sec/sec, in X, Y, Z order.
+	Accel [3]float64
+	// Gyro is the angular velocity in degrees per second, in X, Y, Z order.
+	Gyro [3]float64
+}
+
+// AllanPoint is the Allan deviation of every axis at one averaging time.
+type AllanPoint struct {
+	Tau float64 `json:"tau_sec"`
+	// Accel is in m/sec/sec and Gyro in degrees per second, each in X, Y, Z order.
+	Accel [3]float64 `json:"accel"`
+	Gyro  [3]float64 `json:"gyro"`
+}
+
+// NoiseParameters are the noise terms read off one axis's Allan deviation curve. RandomWalk is the
+// angle random walk in degrees per square root hour for a gyroscope axis, and the velocity random
+// walk in m/sec per square root hour for an accelerometer axis. BiasInstability is in degrees per
+// hour for a gyroscope axis and m/sec/sec for an accelerometer axis. Either is zero if the curve
+// doesn't show it.
+type NoiseParameters struct {
+	RandomWalk         float64 `json:"random_walk"`
+	BiasInstability    float64 `json:"bias_instability"`
+	BiasInstabilityTau float64 `json:"bias_instability_tau_sec"`
+}
+
+// AllanResult is the overlapping Allan deviation of a stationary recording and the noise
+// parameters extracted from it.
+type AllanResult struct {
+	SampleRate float64            `json:"sample_rate_hz"`
+	Samples    int                `json:"samples"`
+	Curve      []AllanPoint       `json:"curve"`
+	Accel      [3]NoiseParameters `json:"accel"`
+	Gyro       [3]NoiseParameters `json:"gyro"`
+}
+
+// AllanDeviation computes the overlapping Allan deviation of every axis of a recording made at a
+// fixed sample rate while the sensor sat still, and extracts the random walk and bias instability
+// of each axis. The longest averaging time is a third of the recording, so characterizing bias
+// instability usually takes hours of data.
+func AllanDeviation(samples []InertialSample, rate float64) (*AllanResult, error) {
+	if rate <= 0 {
+		return nil, errors.New("sample rate must be positive")
+	}
+	if len(samples) < 9 {
+		return nil, errors.Errorf("need at least 9 samples to compute an Allan deviation, got %d", len(samples))
+	}
+
+	tau0 := 1 / rate
+	result := &AllanResult{SampleRate: rate, Samples: len(samples)}
+	clusterSizes := allanClusterSizes(len(samples))
+	result.Curve = make([]AllanPoint, len(clusterSizes))
+	for i, m := range clusterSizes {
+		result.Curve[i].Tau = float64(m) * tau0
+	}
+
+	for axis := range 6 {
+		// theta is the integral of the signal, so that cluster averages are differences of it.
+		theta := make([]float64, len(samples)+1)
+		for i, sample := range samples {
+			value := sample.Gyro[axis%3]
+			if axis < 3 {
+				value = sample.Accel[axis]
+			}
+			theta[i+1] = theta[i] + value*tau0
+		}
+
+		deviations := make([]float64, len(clusterSizes))
+		for i, m := range clusterSizes {
+			deviations[i] = overlappingAllanDeviation(theta, m, tau0)
+			if axis < 3 {
+				result.Curve[i].Accel[axis] = deviations[i]
+			} else {
+				result.Curve[i].Gyro[axis-3] = deviations[i]
+			}
+		}
+
+		params := extractNoiseParameters(result.Curve, deviations)
+		if axis < 3 {
+			result.Accel[axis] = params
+		} else {
+			// Gyroscope parameters are conventionally given per hour rather than per second.
+			params.BiasInstability *= 3600
+			result.Gyro[axis-3] = params
+		}
+	}
+	return result, nil
+}
+
+// allanClusterSizes returns logarithmically spaced cluster sizes, in samples, from 1 up to a third
+// of the recording. Longer clusters have too few independent averages to say much.
+func allanClusterSizes(n int) []int {
+	maxSize := n / 3
+	var sizes []int
+	for i := 0; ; i++ {
+		m := int(math.Floor(math.Pow(10, float64(i)/allanPointsPerDecade)))
+		if m > maxSize {
+			return sizes
+		}
+		if len(sizes) == 0 || m != sizes[len(sizes)-1] {
+			sizes = append(sizes, m)
+		}
+	}
+}
+
+func overlappingAllanDeviation(theta []float64, m int, tau0 float64) float64 {
+	tau := float64(m) * tau0
+	terms := len(theta) - 2*m
+	sum := 0.0
+	for k := 0; k < terms; k++ {
+		d := theta[k+2*m] - 2*theta[k+m] + theta[k]
+		sum += d * d
+	}
+	return math.Sqrt(sum / (2 * tau * tau * float64(terms)))
+}
+
+// extractNoiseParameters reads the random walk off the shortest stretch of the curve with a
+// log-log slope of -1/2, and the bias instability off its minimum.
+func extractNoiseParameters(curve []AllanPoint, deviations []float64) NoiseParameters {
+	var params NoiseParameters
+
+	// Short averaging times have the most independent clusters, so average the random walk over
+	// the first run of points on the -1/2 slope rather than trusting any one of them.
+	sum, count := 0.0, 0
+	for i := 0; i+1 < len(curve); i++ {
+		if deviations[i] <= 0 || deviations[i+1] <= 0 {
+			continue
+		}
+		slope := math.Log(deviations[i+1]/deviations[i]) / math.Log(curve[i+1].Tau/curve[i].Tau)
+		if math.Abs(slope+0.5) > randomWalkSlopeTolerance {
+			if count > 0 {
+				break
+			}
+			continue
+		}
+		// The -1/2 line through this point, evaluated at tau = 1 second.
+		sum += deviations[i] * math.Sqrt(curve[i].Tau)
+		count++
+	}
+	if count > 0 {
+		// Converted from per root second to per root hour.
+		params.RandomWalk = sum / float64(count) * 60
+	}
+
+	// The minimum only reflects bias instability if the curve turns back up after it; a curve that
+	// is still falling at the end of the recording just needed more data.
+	minIndex := 0
+	for i, deviation := range deviations {
+		if deviation < deviations[minIndex] {
+			minIndex = i
+		}
+	}
+	if minIndex < len(deviations)-1 {
+		params.BiasInstability = deviations[minIndex] / biasInstabilityFactor
+		params.BiasInstabilityTau = curve[minIndex].Tau
+	}
+	return params
+}
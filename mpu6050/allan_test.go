@@ -0,0 +1,54 @@
+package mpu6050
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestAllanDeviation(t *testing.T) {
+	const rate = 100.0
+	const gyroSigma = 0.05 // degrees per second
+	const accelSigma = 0.02
+	rng := rand.New(rand.NewSource(1))
+
+	// Ten minutes of white noise, with a slowly wandering bias on the gyroscope's Z axis.
+	samples := make([]InertialSample, 60000)
+	bias := 0.0
+	for i := range samples {
+		bias += rng.NormFloat64() * 1e-4
+		samples[i] = InertialSample{
+			Accel: [3]float64{rng.NormFloat64() * accelSigma, rng.NormFloat64() * accelSigma, gravity + rng.NormFloat64()*accelSigma},
+			Gyro:  [3]float64{rng.NormFloat64() * gyroSigma, rng.NormFloat64() * gyroSigma, rng.NormFloat64()*gyroSigma + bias},
+		}
+	}
+
+	result, err := AllanDeviation(samples, rate)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, result.Samples, test.ShouldEqual, len(samples))
+	test.That(t, result.Curve[0].Tau, test.ShouldAlmostEqual, 1/rate)
+	test.That(t, result.Curve[len(result.Curve)-1].Tau, test.ShouldBeLessThanOrEqualTo, float64(len(samples))/rate/3)
+
+	// For white noise, the deviation at one sample is the noise itself and falls off as 1/sqrt(tau).
+	test.That(t, result.Curve[0].Gyro[0], test.ShouldAlmostEqual, gyroSigma, 0.002)
+	test.That(t, result.Curve[0].Accel[2], test.ShouldAlmostEqual, accelSigma, 0.001)
+	expectedARW := gyroSigma / math.Sqrt(rate) * 60
+	expectedVRW := accelSigma / math.Sqrt(rate) * 60
+	for axis := range 3 {
+		test.That(t, result.Accel[axis].RandomWalk, test.ShouldAlmostEqual, expectedVRW, 0.1*expectedVRW)
+		test.That(t, result.Gyro[axis].RandomWalk, test.ShouldAlmostEqual, expectedARW, 0.1*expectedARW)
+	}
+
+	// The wandering bias makes the Z curve turn back up, leaving a minimum to read bias instability
+	// off.
+	test.That(t, result.Gyro[2].BiasInstability, test.ShouldBeGreaterThan, 0)
+	test.That(t, result.Gyro[2].BiasInstabilityTau, test.ShouldBeGreaterThan, result.Curve[0].Tau)
+	test.That(t, result.Gyro[2].BiasInstabilityTau, test.ShouldBeLessThan, result.Curve[len(result.Curve)-1].Tau)
+
+	_, err = AllanDeviation(samples[:5], rate)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = AllanDeviation(samples, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,81 @@
+package mpu6050
+
+import (
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// One second of samples at the scheduler's rate.
+const defaultBatchMaxSamples = 1000
+
+// Batch makes every Readings call return each sample taken since the previous call, so that data
+// capture running slower than the chip is sampled still records every sample.
+type Batch struct {
+	// MaxSamples bounds how many samples are kept between calls. Beyond it, the oldest are dropped.
+	MaxSamples int `json:"max_samples,omitempty"`
+}
+
+func (b *Batch) validate() error {
+	if b.MaxSamples < 0 {
+		return errors.Errorf("batch.max_samples must not be negative, got %d", b.MaxSamples)
+	}
+	return nil
+}
+
+func (b *Batch) maxSamples() int {
+	if b.MaxSamples == 0 {
+		return defaultBatchMaxSamples
+	}
+	return b.MaxSamples
+}
+
+// batchedSample is one sample, as reported.
+type batchedSample struct {
+	time        time.Time
+	accel       r3.Vector // m/sec/sec
+	gyro        spatialmath.AngularVelocity
+	temperature float64
+	raw         rawSample
+}
+
+// sampleBatch collects the samples taken since the last Readings call.
+type sampleBatch struct {
+	maxSamples int
+	samples    []batchedSample
+	// dropped counts the samples discarded since the last Readings call because the batch was full.
+	dropped int
+}
+
+func newSampleBatch(conf Batch) *sampleBatch {
+	return &sampleBatch{maxSamples: conf.maxSamples()}
+}
+
+func (sb *sampleBatch) add(sample batchedSample) {
+	if len(sb.samples) >= sb.maxSamples {
+		sb.samples = sb.samples[1:]
+		sb.dropped++
+	}
+	sb.samples = append(sb.samples, sample)
+}
+
+// take returns the readings for every sample collected, oldest first, and starts a new batch.
+// Each sample has the same keys as the flat readings, plus its time and temperature.
+func (sb *sampleBatch) take(flat *FlatReadings, maxAcceleration, maxRotation float64) map[string]interface{} {
+	samples := make([]interface{}, 0, len(sb.samples))
+	for _, sample := range sb.samples {
+		readings := flat.readings(sample.accel, sample.gyro, sample.raw, maxAcceleration, maxRotation)
+		readings["time_unix_sec"] = float64(sample.time.UnixNano()) / float64(time.Second)
+		readings["temperature_celsius"] = sample.temperature
+		samples = append(samples, readings)
+	}
+	readings := map[string]interface{}{
+		"samples":         samples,
+		"samples_dropped": sb.dropped,
+	}
+	sb.samples = nil
+	sb.dropped = 0
+	return readings
+}
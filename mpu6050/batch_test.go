@@ -0,0 +1,97 @@
+package mpu6050
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestSampleBatch(t *testing.T) {
+	test.That(t, (&Batch{MaxSamples: -1}).validate(), test.ShouldNotBeNil)
+
+	sb := newSampleBatch(Batch{MaxSamples: 3})
+	start := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		sb.add(batchedSample{time: start.Add(time.Duration(i) * time.Millisecond), accel: r3.Vector{Z: float64(i)}})
+	}
+
+	// Only the newest samples are kept, oldest first.
+	readings := sb.take(&FlatReadings{AccelUnit: accelUnitG}, 2*gravity, 250)
+	test.That(t, readings["samples_dropped"], test.ShouldEqual, 2)
+	samples := readings["samples"].([]interface{})
+	test.That(t, samples, test.ShouldHaveLength, 3)
+	for i, sample := range samples {
+		values := sample.(map[string]interface{})
+		test.That(t, values["time_unix_sec"], test.ShouldAlmostEqual, 1000+float64(i+2)/1000)
+		test.That(t, values["linear_acceleration_z_g"], test.ShouldAlmostEqual, float64(i+2)/gravity)
+	}
+
+	readings = sb.take(&FlatReadings{}, 2*gravity, 250)
+	test.That(t, readings["samples"], test.ShouldBeEmpty)
+	test.That(t, readings["samples_dropped"], test.ShouldEqual, 0)
+}
+
+func TestBatchedReadings(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	conf := &Config{I2cBus: i2cName, Batch: &Batch{}}
+	sensor, err := makeMpu6050(ctx, logger, testName, conf, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	_, err = sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	var samples []interface{}
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		samples = append(samples, readings["samples"].([]interface{})...)
+		test.That(tb, len(samples), test.ShouldBeGreaterThan, 20)
+	})
+
+	// No sample is returned twice, and they come in order.
+	var last float64
+	for _, sample := range samples {
+		values := sample.(map[string]interface{})
+		test.That(t, values["linear_acceleration_z_mps2"], test.ShouldAlmostEqual, 9.81)
+		test.That(t, values["time_unix_sec"], test.ShouldBeGreaterThan, last)
+		last = values["time_unix_sec"].(float64)
+	}
+}
+
+func TestBatchedReadingsUnits(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// Without flat_readings, the samples' angular velocity follows angular_velocity_unit.
+	mockData := make([]byte, 16)
+	mockData[9] = 131
+	conf := &Config{I2cBus: i2cName, Batch: &Batch{}, AngularVelocityUnit: gyroUnitRadPS}
+	sensor, err := makeMpu6050(ctx, logger, testName, conf, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	_, err = sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		samples, _ := readings["samples"].([]interface{})
+		test.That(tb, samples, test.ShouldNotBeEmpty)
+		if len(samples) == 0 {
+			return
+		}
+		values, _ := samples[len(samples)-1].(map[string]interface{})
+		test.That(tb, values, test.ShouldNotContainKey, "angular_velocity_x_dps")
+		test.That(tb, values["angular_velocity_x_radps"], test.ShouldAlmostEqual, utils.DegToRad(1), 1e-4)
+	})
+}
@@ -0,0 +1,137 @@
+package mpu6050
+
+import (
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultGyroBiasTimeConstant = 10.0 // seconds
+	defaultGyroBiasMaxRate      = 0.05 // degrees per second, per second
+	defaultGyroBiasMaxBias      = 5.0  // degrees per second
+
+	gyroBiasSourceStillness = "stillness"
+	gyroBiasSourceFilter    = "filter"
+)
+
+// GyroBias configures online gyroscope bias estimation, which tracks the bias as it drifts and
+// subtracts it on top of any calibration. The estimate comes either from the readings taken while
+// the stillness detector says the sensor is still, when the true angular velocity is zero, or from
+// the bias that the orientation filter learns while correcting roll and pitch. The filter keeps
+// learning while the sensor moves, but can't see bias about the vertical axis.
+type GyroBias struct {
+	Source string `json:"source,omitempty"`
+	// TimeConstantSec is how long the still readings are averaged over.
+	TimeConstantSec float64 `json:"time_constant_sec,omitempty"`
+	// MaxRate limits how quickly the estimate can change, in degrees per second per second, so that
+	// a bad stretch of readings can't throw it far off.
+	MaxRate float64 `json:"max_rate_dps_per_sec,omitempty"`
+	// MaxBias bounds each axis of the estimate, in degrees per second.
+	MaxBias float64 `json:"max_bias_dps,omitempty"`
+}
+
+func (gb *GyroBias) validate() error {
+	switch gb.Source {
+	case "", gyroBiasSourceStillness, gyroBiasSourceFilter:
+	default:
+		return errors.Errorf("gyro_bias.source must be %q or %q, got %q",
+			gyroBiasSourceStillness, gyroBiasSourceFilter, gb.Source)
+	}
+	if gb.TimeConstantSec < 0 || gb.MaxRate < 0 || gb.MaxBias < 0 {
+		return errors.New("gyro_bias settings must not be negative")
+	}
+	return nil
+}
+
+func (gb *GyroBias) source() string {
+	if gb.Source == "" {
+		return gyroBiasSourceStillness
+	}
+	return gb.Source
+}
+
+func (gb *GyroBias) timeConstant() float64 {
+	if gb.TimeConstantSec == 0 {
+		return defaultGyroBiasTimeConstant
+	}
+	return gb.TimeConstantSec
+}
+
+func (gb *GyroBias) maxRate() float64 {
+	if gb.MaxRate == 0 {
+		return defaultGyroBiasMaxRate
+	}
+	return gb.MaxRate
+}
+
+func (gb *GyroBias) maxBias() float64 {
+	if gb.MaxBias == 0 {
+		return defaultGyroBiasMaxBias
+	}
+	return gb.MaxBias
+}
+
+// gyroBiasEstimator tracks the gyroscope bias, in degrees per second.
+type gyroBiasEstimator struct {
+	conf GyroBias
+
+	bias r3.Vector
+	// updated is when the estimate last changed, or zero if it never has.
+	updated time.Time
+	frozen  bool
+}
+
+func newGyroBiasEstimator(conf GyroBias) *gyroBiasEstimator {
+	return &gyroBiasEstimator{conf: conf}
+}
+
+// update moves the estimate towards the bias that is left over after the current estimate has
+// been removed, and returns how much it moved. From stillness, the residual is a still reading,
+// which is averaged; from the filter, it is the filter's own bias estimate, which is already
+// averaged and is taken over whole. Either way, the change is rate limited and the result bounded.
+func (be *gyroBiasEstimator) update(now time.Time, residual r3.Vector, dt float64) r3.Vector {
+	if be.frozen || dt <= 0 {
+		return r3.Vector{}
+	}
+	step := residual
+	if be.conf.source() == gyroBiasSourceStillness {
+		step = residual.Mul(dt / (be.conf.timeConstant() + dt))
+	}
+	limit := be.conf.maxRate() * dt
+	bound := be.conf.maxBias()
+	target := be.bias.Add(r3.Vector{X: clamp(step.X, limit), Y: clamp(step.Y, limit), Z: clamp(step.Z, limit)})
+	target = r3.Vector{X: clamp(target.X, bound), Y: clamp(target.Y, bound), Z: clamp(target.Z, bound)}
+
+	change := target.Sub(be.bias)
+	be.bias = target
+	be.updated = now
+	return change
+}
+
+// reset zeroes the estimate. It stays frozen if it was.
+func (be *gyroBiasEstimator) reset() {
+	be.bias = r3.Vector{}
+	be.updated = time.Time{}
+}
+
+// readings reports the estimate and how long ago it last changed.
+func (be *gyroBiasEstimator) readings(now time.Time) map[string]interface{} {
+	readings := map[string]interface{}{
+		"gyro_bias_x_dps":  be.bias.X,
+		"gyro_bias_y_dps":  be.bias.Y,
+		"gyro_bias_z_dps":  be.bias.Z,
+		"gyro_bias_frozen": be.frozen,
+	}
+	if !be.updated.IsZero() {
+		readings["gyro_bias_age_sec"] = now.Sub(be.updated).Seconds()
+	}
+	return readings
+}
+
+// clamp limits value to between -limit and limit.
+func clamp(value, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, value))
+}
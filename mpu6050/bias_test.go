@@ -0,0 +1,100 @@
+package mpu6050
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/test"
+)
+
+func TestGyroBiasFromStillness(t *testing.T) {
+	be := newGyroBiasEstimator(GyroBias{MaxRate: 1})
+	bias := r3.Vector{X: 0.5, Y: -0.25, Z: 1}
+	now := time.Now()
+	dt := 0.001
+
+	// Still readings converge on the bias within a few time constants, feeding back the estimate
+	// as the driver does.
+	for range int(5 * defaultGyroBiasTimeConstant / dt) {
+		now = now.Add(time.Millisecond)
+		be.update(now, bias.Sub(be.bias), dt)
+	}
+	test.That(t, be.bias.Sub(bias).Norm(), test.ShouldBeLessThan, 0.01)
+	test.That(t, be.updated, test.ShouldEqual, now)
+	readings := be.readings(now.Add(time.Second))
+	test.That(t, readings["gyro_bias_z_dps"], test.ShouldAlmostEqual, 1, 0.01)
+	test.That(t, readings["gyro_bias_age_sec"], test.ShouldAlmostEqual, 1)
+
+	// Frozen, it doesn't move.
+	be.frozen = true
+	frozenAt := be.bias
+	test.That(t, be.update(now, r3.Vector{X: 100}, dt), test.ShouldResemble, r3.Vector{})
+	test.That(t, be.bias, test.ShouldResemble, frozenAt)
+
+	be.reset()
+	test.That(t, be.bias, test.ShouldResemble, r3.Vector{})
+	readings = be.readings(now)
+	test.That(t, readings["gyro_bias_frozen"], test.ShouldBeTrue)
+	test.That(t, readings, test.ShouldNotContainKey, "gyro_bias_age_sec")
+}
+
+func TestGyroBiasLimits(t *testing.T) {
+	be := newGyroBiasEstimator(GyroBias{Source: gyroBiasSourceFilter, MaxRate: 0.1, MaxBias: 2})
+	now := time.Now()
+
+	// The whole residual would be taken over at once, but the rate is limited.
+	change := be.update(now, r3.Vector{X: 1, Y: -1, Z: 0.01}, 1)
+	test.That(t, change.X, test.ShouldAlmostEqual, 0.1)
+	test.That(t, change.Y, test.ShouldAlmostEqual, -0.1)
+	test.That(t, change.Z, test.ShouldAlmostEqual, 0.01)
+
+	// And the estimate is bounded.
+	for range 100 {
+		be.update(now, r3.Vector{X: 10}, 1)
+	}
+	test.That(t, be.bias.X, test.ShouldAlmostEqual, 2)
+
+	test.That(t, (&GyroBias{Source: "magic"}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&GyroBias{MaxBias: -1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&GyroBias{Source: gyroBiasSourceFilter}).validate(), test.ShouldBeNil)
+}
+
+func TestGyroBiasFromFilter(t *testing.T) {
+	filter := newOrientationFilter()
+	be := newGyroBiasEstimator(GyroBias{Source: gyroBiasSourceFilter, MaxRate: 1})
+	level := r3.Vector{Z: gravity}
+	bias := r3.Vector{X: 0.5, Y: -0.5}
+	now := time.Now()
+	dt := 0.001
+	filter.update(level, r3.Vector{}, 0)
+
+	// The filter learns the roll and pitch bias, and the estimator takes it over.
+	for range 600000 {
+		now = now.Add(time.Millisecond)
+		gyro := bias.Sub(be.bias).Mul(utils.DegToRad(1))
+		filter.update(level, gyro, dt)
+		change := be.update(now, filter.integral.Mul(-utils.RadToDeg(1)), dt)
+		filter.integral = filter.integral.Add(change.Mul(utils.DegToRad(1)))
+	}
+	test.That(t, be.bias.X, test.ShouldAlmostEqual, bias.X, 0.02)
+	test.That(t, be.bias.Y, test.ShouldAlmostEqual, bias.Y, 0.02)
+	test.That(t, filter.integral.Norm(), test.ShouldBeLessThan, utils.DegToRad(0.02))
+}
+
+func TestRefineGyroBiasAlias(t *testing.T) {
+	test.That(t, (&Config{}).gyroBias(), test.ShouldBeNil)
+	test.That(t, (&Config{Stillness: &Stillness{}}).gyroBias(), test.ShouldBeNil)
+
+	// The old stillness attribute turns on the stillness-sourced estimator with its defaults.
+	conf := &Config{Stillness: &Stillness{RefineGyroBias: true}}
+	test.That(t, conf.gyroBias(), test.ShouldResemble, &GyroBias{})
+	test.That(t, conf.gyroBias().source(), test.ShouldEqual, gyroBiasSourceStillness)
+	test.That(t, conf.needsOrientation(), test.ShouldBeFalse)
+
+	// gyro_bias wins if both are set.
+	conf.GyroBias = &GyroBias{Source: gyroBiasSourceFilter}
+	test.That(t, conf.gyroBias(), test.ShouldEqual, conf.GyroBias)
+	test.That(t, conf.needsOrientation(), test.ShouldBeTrue)
+}
@@ -0,0 +1,15 @@
+package mpu6050
+
+import (
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+)
+
+// openI2CBus opens an I2C bus on the host by its name, such as "1" for /dev/i2c-1.
+func openI2CBus(name string) (buses.I2C, error) {
+	return buses.NewI2cBus(name)
+}
+
+// openSPIBus opens an SPI bus on the host by its name, such as "0" for /dev/spidev0.*.
+func openSPIBus(name string) (buses.SPI, error) {
+	return buses.NewSpiBus(name), nil
+}
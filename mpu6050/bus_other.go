@@ -0,0 +1,21 @@
+//go:build !linux
+
+package mpu6050
+
+import (
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+)
+
+// Only Linux exposes I2C and SPI buses in a way we know how to open. Everything else in this
+// package works on any platform, given a bus from a board or a test.
+var errNoHostBuses = errors.New("opening I2C and SPI buses directly is only supported on Linux; " +
+	"set board to a board component that supplies the I2C bus")
+
+func openI2CBus(name string) (buses.I2C, error) {
+	return nil, errNoHostBuses
+}
+
+func openSPIBus(name string) (buses.SPI, error) {
+	return nil, errNoHostBuses
+}
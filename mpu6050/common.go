@@ -1,33 +1,373 @@
-// Package mpu6050 is only implemented for Linux systems.
 package mpu6050
 
 import (
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board/genericlinux/buses"
 	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/components/sensor"
 	"go.viam.com/rdk/resource"
 )
 
 // Model for viam supported tdk-invensense mpu6050 movement sensor.
 var Model = resource.NewModel("viam", "tdk-invensense", "mpu6050")
 
+const (
+	defaultGyroRange  = 250 // degrees per second
+	defaultAccelRange = 2   // multiples of g
+
+	// SPI clock speeds. Every register can be accessed at up to 1MHz, and the sensor registers can
+	// be read at up to 20MHz.
+	defaultSPIRegisterSpeed = 1000000  // Hz
+	defaultSPISensorSpeed   = 20000000 // Hz
+
+	// The module samples every chip at 1kHz by default. Over SPI, it can keep up with the
+	// gyroscope's 8kHz output rate.
+	defaultSampleRate = 1000 // Hz
+	maxSPISampleRate  = 8000 // Hz
+
+	// The TCA9548A's address is 0x70 through 0x77, set by its A0-A2 pins, and it has 8 channels.
+	defaultMuxAddress = 0x70
+	maxMuxAddress     = 0x77
+	muxChannels       = 8
+)
+
+// The full-scale ranges supported by the chip, mapped to the value of the FS_SEL (gyroscope) or
+// AFS_SEL (accelerometer) field in the corresponding configuration register.
+var (
+	gyroRanges  = map[int]byte{250: 0, 500: 1, 1000: 2, 2000: 3}
+	accelRanges = map[int]byte{2: 0, 4: 1, 8: 2, 16: 3}
+)
+
 // Config is used to configure the attributes of the chip.
 type Config struct {
-	I2cBus                 string `json:"i2c_bus"`
+	// Board, if set, is the board component that supplies I2cBus. It must implement I2CProvider.
+	// Otherwise, I2cBus is opened directly on the Linux host.
+	Board                  string `json:"board,omitempty"`
+	I2cBus                 string `json:"i2c_bus,omitempty"`
 	UseAlternateI2CAddress bool   `json:"use_alt_i2c_address,omitempty"`
+	// I2cAddress overrides the address chosen by UseAlternateI2CAddress, and Mux describes the
+	// multiplexer channel the chip is behind, if any.
+	I2cAddress int  `json:"i2c_address,omitempty"`
+	Mux        *Mux `json:"mux,omitempty"`
+	// SPI connects to the chip over SPI instead of I2C, for the MPU-6000. Set it or I2cBus, but not
+	// both.
+	SPI         *SPI         `json:"spi,omitempty"`
+	GyroRange   int          `json:"gyro_range_dps,omitempty"`
+	AccelRange  int          `json:"accel_range_g,omitempty"`
+	Calibration *Calibration `json:"calibration,omitempty"`
+	// TemperatureCompensation models the gyroscope bias against temperature. It replaces
+	// Calibration's gyroscope bias, so they can't both be set.
+	TemperatureCompensation *TemperatureCompensation `json:"temperature_compensation,omitempty"`
+	EstimateLinearVelocity  bool                     `json:"estimate_linear_velocity,omitempty"`
+	// Gravity compensation options: all of them run the orientation filter to find gravity.
+	RemoveGravity          bool       `json:"remove_gravity,omitempty"`
+	ReportUserAcceleration bool       `json:"report_user_acceleration,omitempty"`
+	ReportWorldFrame       bool       `json:"report_world_frame,omitempty"`
+	Mounting               *Mounting  `json:"mounting,omitempty"`
+	Vibration              *Vibration `json:"vibration,omitempty"`
+	Shock                  *Shock     `json:"shock,omitempty"`
+	Tilt                   *Tilt      `json:"tilt,omitempty"`
+	Pedometer              *Pedometer `json:"pedometer,omitempty"`
+	Tap                    *Tap       `json:"tap,omitempty"`
+	Stillness              *Stillness `json:"stillness,omitempty"`
+	GyroBias               *GyroBias  `json:"gyro_bias,omitempty"`
+	// FlatReadings adds per-axis values in selectable units, and optionally raw counts, to Readings.
+	FlatReadings *FlatReadings `json:"flat_readings,omitempty"`
+	// Batch makes Readings return every sample since the previous call.
+	Batch *Batch `json:"batch,omitempty"`
+	// AngularVelocityUnit is the unit AngularVelocity and the angular_velocity reading are in:
+	// degrees per second, as the movement sensor API specifies, or radians per second.
+	AngularVelocityUnit string `json:"angular_velocity_unit,omitempty"`
+	// StuckDataSamples is how many identical samples in a row count as stuck data. Zero uses the
+	// default, and a negative value turns the check off.
+	StuckDataSamples int `json:"stuck_data_samples,omitempty"`
+}
+
+// Calibration holds per-axis offsets that are subtracted from every sample read off the chip. The
+// cmdlocal "calibrate" command prints a config snippet containing these values.
+type Calibration struct {
+	GyroBias  []float64 `json:"gyro_bias_dps,omitempty"`
+	AccelBias []float64 `json:"accel_bias_mps2,omitempty"`
+}
+
+// I2CProvider is implemented by boards that can supply an I2C bus by name. The board API has no
+// way to get at a bus, so boards that want to supply one to this sensor need this method.
+type I2CProvider interface {
+	I2CByName(name string) (buses.I2C, error)
+}
+
+// Mux describes the TCA9548A I2C multiplexer channel that the chip is wired to.
+type Mux struct {
+	Address int `json:"address,omitempty"`
+	Channel int `json:"channel"`
+}
+
+func (m *Mux) validate() error {
+	if m.Address != 0 && (m.Address < defaultMuxAddress || m.Address > maxMuxAddress) {
+		return errors.Errorf("mux.address must be between %#x and %#x, got %#x", defaultMuxAddress, maxMuxAddress, m.Address)
+	}
+	if m.Channel < 0 || m.Channel >= muxChannels {
+		return errors.Errorf("mux.channel must be between 0 and %d, got %d", muxChannels-1, m.Channel)
+	}
+	return nil
+}
+
+func (m *Mux) address() int {
+	if m.Address == 0 {
+		return defaultMuxAddress
+	}
+	return m.Address
+}
+
+// SPI selects the SPI bus and chip select that the chip is wired to, and the clock speeds to use.
+type SPI struct {
+	Bus        string `json:"spi_bus"`
+	ChipSelect string `json:"chip_select"`
+	// RegisterSpeedHz is the clock speed for configuring the chip, and SensorSpeedHz the faster one
+	// for reading its sensor data, interrupt status, and FIFO.
+	RegisterSpeedHz int `json:"register_speed_hz,omitempty"`
+	SensorSpeedHz   int `json:"sensor_speed_hz,omitempty"`
+	// SampleRateHz is how often the chip is sampled. Every chip on the same SPI bus must use the
+	// same rate, since they share a scheduler.
+	SampleRateHz int `json:"sample_rate_hz,omitempty"`
+}
+
+func (s *SPI) validate() error {
+	if s.Bus == "" {
+		return errors.New("spi.spi_bus is required")
+	}
+	if s.ChipSelect == "" {
+		return errors.New("spi.chip_select is required")
+	}
+	if s.RegisterSpeedHz < 0 || s.RegisterSpeedHz > defaultSPIRegisterSpeed {
+		return errors.Errorf("spi.register_speed_hz must be at most %d, got %d", defaultSPIRegisterSpeed, s.RegisterSpeedHz)
+	}
+	if s.SensorSpeedHz < 0 || s.SensorSpeedHz > defaultSPISensorSpeed {
+		return errors.Errorf("spi.sensor_speed_hz must be at most %d, got %d", defaultSPISensorSpeed, s.SensorSpeedHz)
+	}
+	if s.SampleRateHz < 0 || s.SampleRateHz > maxSPISampleRate {
+		return errors.Errorf("spi.sample_rate_hz must be at most %d, got %d", maxSPISampleRate, s.SampleRateHz)
+	}
+	return nil
+}
+
+func (s *SPI) registerSpeed() int {
+	if s.RegisterSpeedHz == 0 {
+		return defaultSPIRegisterSpeed
+	}
+	return s.RegisterSpeedHz
+}
+
+func (s *SPI) sensorSpeed() int {
+	if s.SensorSpeedHz == 0 {
+		return defaultSPISensorSpeed
+	}
+	return s.SensorSpeedHz
+}
+
+// sampleRate returns how often the chip is sampled, in Hz. Only SPI can go faster than the default.
+func (conf *Config) sampleRate() int {
+	if conf.SPI == nil || conf.SPI.SampleRateHz == 0 {
+		return defaultSampleRate
+	}
+	return conf.SPI.SampleRateHz
+}
+
+// samplePeriod returns the time between samples.
+func (conf *Config) samplePeriod() time.Duration {
+	return time.Second / time.Duration(conf.sampleRate())
 }
 
 // Validate ensures all parts of the config are valid, and then returns the list of things we
 // depend on.
 func (conf *Config) Validate(path string) ([]string, error) {
-	if conf.I2cBus == "" {
+	if conf.SPI != nil {
+		if conf.I2cBus != "" || conf.UseAlternateI2CAddress || conf.I2cAddress != 0 || conf.Mux != nil || conf.Board != "" {
+			return nil, resource.NewConfigValidationError(path,
+				errors.New("set either spi or the I2C attributes, but not both"))
+		}
+		if err := conf.SPI.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	} else if conf.I2cBus == "" {
 		return nil, resource.NewConfigValidationFieldRequiredError(path, "i2c_bus")
 	}
+	if conf.I2cAddress != 0 {
+		if conf.UseAlternateI2CAddress {
+			return nil, resource.NewConfigValidationError(path,
+				errors.New("set either i2c_address or use_alt_i2c_address, but not both"))
+		}
+		// Addresses outside this range are reserved by the I2C specification.
+		if conf.I2cAddress < 0x08 || conf.I2cAddress > 0x77 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("i2c_address must be between 0x08 and 0x77, got %#x", conf.I2cAddress))
+		}
+	}
+	if conf.Mux != nil {
+		if err := conf.Mux.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if _, ok := gyroRanges[conf.gyroRange()]; !ok {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("gyro_range_dps must be one of 250, 500, 1000, or 2000, got %d", conf.GyroRange))
+	}
+	if unit := conf.angularVelocityUnit(); unit != gyroUnitDPS && unit != gyroUnitRadPS {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("angular_velocity_unit must be %q or %q, got %q", gyroUnitDPS, gyroUnitRadPS, unit))
+	}
+	if _, ok := accelRanges[conf.accelRange()]; !ok {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("accel_range_g must be one of 2, 4, 8, or 16, got %d", conf.AccelRange))
+	}
+	if conf.Calibration != nil {
+		if err := conf.Calibration.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Mounting != nil {
+		if _, err := conf.Mounting.rotation(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.TemperatureCompensation != nil {
+		if err := conf.TemperatureCompensation.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+		// The model's constant term already holds the bias at the reference temperature, so
+		// subtracting a calibrated bias as well would remove it twice.
+		if conf.Calibration != nil && conf.Calibration.GyroBias != nil {
+			return nil, resource.NewConfigValidationError(path,
+				errors.New("set either calibration.gyro_bias_dps or temperature_compensation, not both"))
+		}
+	}
+	if conf.Vibration != nil {
+		if err := conf.Vibration.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+		// Vibration analysis slows the chip's sample rate down to the accelerometer's 1kHz.
+		if conf.sampleRate() > defaultSampleRate {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("vibration can't be used with spi.sample_rate_hz above %d", defaultSampleRate))
+		}
+	}
+	if conf.Shock != nil {
+		if err := conf.Shock.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Tilt != nil {
+		if err := conf.Tilt.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Pedometer != nil {
+		if err := conf.Pedometer.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Tap != nil {
+		if err := conf.Tap.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Stillness != nil {
+		if err := conf.Stillness.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.GyroBias != nil {
+		if err := conf.GyroBias.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.FlatReadings != nil {
+		if err := conf.FlatReadings.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if conf.Batch != nil {
+		if err := conf.Batch.validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, err)
+		}
+	}
 
 	var deps []string
+	if conf.Board != "" {
+		deps = append(deps, conf.Board)
+	}
 	return deps, nil
 }
 
+func (cal *Calibration) validate() error {
+	if cal.GyroBias != nil && len(cal.GyroBias) != 3 {
+		return errors.Errorf("calibration.gyro_bias_dps must have 3 values, got %d", len(cal.GyroBias))
+	}
+	if cal.AccelBias != nil && len(cal.AccelBias) != 3 {
+		return errors.Errorf("calibration.accel_bias_mps2 must have 3 values, got %d", len(cal.AccelBias))
+	}
+	return nil
+}
+
+// gyroRange returns the configured gyroscope range in degrees per second, or the chip's default.
+func (conf *Config) gyroRange() int {
+	if conf.GyroRange == 0 {
+		return defaultGyroRange
+	}
+	return conf.GyroRange
+}
+
+// accelRange returns the configured accelerometer range in g, or the chip's default.
+func (conf *Config) accelRange() int {
+	if conf.AccelRange == 0 {
+		return defaultAccelRange
+	}
+	return conf.AccelRange
+}
+
+// angularVelocityUnit returns the unit angular velocity is reported in.
+func (conf *Config) angularVelocityUnit() string {
+	if conf.AngularVelocityUnit == "" {
+		return gyroUnitDPS
+	}
+	return conf.AngularVelocityUnit
+}
+
+// stuckDataSamples returns how many identical samples in a row count as stuck data, or 0 if the
+// check is off.
+func (conf *Config) stuckDataSamples() int {
+	switch {
+	case conf.StuckDataSamples == 0:
+		return defaultStuckDataSamples
+	case conf.StuckDataSamples < 0:
+		return 0
+	default:
+		return conf.StuckDataSamples
+	}
+}
+
+// needsOrientation returns whether any enabled feature depends on the orientation filter.
+func (conf *Config) needsOrientation() bool {
+	return conf.EstimateLinearVelocity || conf.RemoveGravity || conf.ReportUserAcceleration || conf.ReportWorldFrame ||
+		(conf.gyroBias() != nil && conf.gyroBias().source() == gyroBiasSourceFilter)
+}
+
+// gyroBias returns the online gyroscope bias settings, or nil if it is off. stillness.refine_gyro_bias
+// turns it on with its defaults, unless gyro_bias is also set.
+func (conf *Config) gyroBias() *GyroBias {
+	if conf.GyroBias == nil && conf.Stillness != nil && conf.Stillness.RefineGyroBias {
+		return &GyroBias{}
+	}
+	return conf.GyroBias
+}
+
 func init() {
 	resource.RegisterComponent(movementsensor.API, Model, resource.Registration[movementsensor.MovementSensor, *Config]{
 		Constructor: newMpu6050,
 	})
+	resource.RegisterComponent(sensor.API, TemperatureModel, resource.Registration[sensor.Sensor, *TemperatureConfig]{
+		Constructor: newTemperatureSensor,
+	})
 }
@@ -0,0 +1,294 @@
+package mpu6050
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/utils"
+)
+
+const selfTestSamples = 50
+
+// ScanResult describes a device that answered on one of the two MPU I2C addresses.
+type ScanResult struct {
+	Address byte
+	WhoAmI  byte
+	// Model is empty if the WHO_AM_I value is not one we recognize.
+	Model string
+}
+
+// SelfTestAxis is the self-test outcome for a single axis of the gyroscope or accelerometer.
+type SelfTestAxis struct {
+	// FactoryTrim and Response are both in raw counts.
+	FactoryTrim      float64
+	Response         float64
+	DeviationPercent float64
+	Passed           bool
+}
+
+// SelfTestResult holds the outcome of the chip's built-in self-test, in X, Y, Z order.
+type SelfTestResult struct {
+	Gyro  [3]SelfTestAxis
+	Accel [3]SelfTestAxis
+}
+
+// Passed returns whether every axis passed the self-test.
+func (result *SelfTestResult) Passed() bool {
+	for i := range 3 {
+		if !result.Gyro[i].Passed || !result.Accel[i].Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// openChip gives back an mpu6050 that can talk to the chip but has no background worker, so that
+// the diagnostics below use the same register access path as the driver itself.
+func openChip(logger logging.Logger, conf *Config) (*mpu6050, error) {
+	tr, err := conf.newTransport(nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &mpu6050{transport: tr, logger: logger}, nil
+}
+
+// Scan probes both MPU I2C addresses on the named bus and reports the devices that respond.
+func Scan(ctx context.Context, logger logging.Logger, busName string) ([]ScanResult, error) {
+	var results []ScanResult
+	for _, address := range []byte{expectedDefaultAddress, alternateAddress} {
+		chip, err := openChip(logger, &Config{I2cBus: busName, UseAlternateI2CAddress: address == alternateAddress})
+		if err != nil {
+			return nil, err
+		}
+		whoAmI, err := chip.readByte(ctx, defaultAddressRegister)
+		if err != nil {
+			// Nothing is listening at this address.
+			logger.CDebugf(ctx, "no response at address %#x: %s", address, err)
+			continue
+		}
+		results = append(results, ScanResult{Address: address, WhoAmI: whoAmI, Model: chipModels[whoAmI].name})
+	}
+	return results, nil
+}
+
+// DumpRegisters reads every register from 0 through WHO_AM_I on the chip described by the config.
+// Registers that reading would consume, such as FIFO_R_W, are skipped and left at 0; check them
+// with ConsumedOnRead.
+func DumpRegisters(ctx context.Context, logger logging.Logger, conf *Config) ([]byte, error) {
+	chip, err := openChip(logger, conf)
+	if err != nil {
+		return nil, err
+	}
+	return chip.dumpRegisters(ctx)
+}
+
+func (mpu *mpu6050) dumpRegisters(ctx context.Context) ([]byte, error) {
+	dump := make([]byte, registerCount)
+	// Read each run of registers between the skipped ones as a single block.
+	start := 0
+	for start < registerCount {
+		if ConsumedOnRead(byte(start)) {
+			start++
+			continue
+		}
+		end := start
+		for end < registerCount && !ConsumedOnRead(byte(end)) {
+			end++
+		}
+		values, err := mpu.readBlock(ctx, byte(start), uint8(end-start))
+		if err != nil {
+			return nil, readError(err, mpu.transport)
+		}
+		copy(dump[start:end], values)
+		start = end
+	}
+	return dump, nil
+}
+
+// SelfTest runs the chip's built-in self-test, as described in section 4 of the register map. The
+// chip is left awake in its default ranges afterwards.
+func SelfTest(ctx context.Context, logger logging.Logger, conf *Config) (*SelfTestResult, error) {
+	chip, err := openChip(logger, conf)
+	if err != nil {
+		return nil, err
+	}
+	whoAmI, err := chip.readByte(ctx, defaultAddressRegister)
+	if err != nil {
+		return nil, readError(err, chip.transport)
+	}
+	model, ok := chipModels[whoAmI]
+	if !ok {
+		return nil, unexpectedDeviceError(chip.transport, whoAmI)
+	}
+	spec := model.selfTest
+	if err := chip.writeByte(ctx, powerRegister, 0); err != nil {
+		return nil, errors.Wrap(err, "unable to wake up MPU6050")
+	}
+
+	gyroTrim, accelTrim, err := spec.factoryTrim(ctx, chip)
+	if err != nil {
+		return nil, readError(err, chip.transport)
+	}
+
+	// The self-test runs with the gyroscope at +/- 250 deg/sec.
+	normal, err := chip.averageSelfTestOutputs(ctx, 0, spec.accelRangeBits)
+	if err != nil {
+		return nil, err
+	}
+	// Bits 5 through 7 of both config registers enable the self-test on each axis.
+	enabled, err := chip.averageSelfTestOutputs(ctx, 0xE0, 0xE0|spec.accelRangeBits)
+	if err != nil {
+		return nil, err
+	}
+	if err := chip.writeByte(ctx, gyroConfigRegister, gyroRanges[defaultGyroRange]<<3); err != nil {
+		return nil, err
+	}
+	if err := chip.writeByte(ctx, accelConfigRegister, accelRanges[defaultAccelRange]<<3); err != nil {
+		return nil, err
+	}
+
+	result := &SelfTestResult{}
+	for axis := range 3 {
+		result.Gyro[axis] = checkSelfTestAxis(gyroTrim[axis], enabled[axis+3]-normal[axis+3], spec.gyroLimits)
+		result.Accel[axis] = checkSelfTestAxis(accelTrim[axis], enabled[axis]-normal[axis], spec.accelLimits)
+	}
+	return result, nil
+}
+
+// selfTestSpec describes how a chip runs its self-test.
+type selfTestSpec struct {
+	// accelRangeBits selects the accelerometer range the self-test runs at, in ACCEL_CONFIG.
+	accelRangeBits byte
+	// factoryTrim reads the factory trim registers, and returns the expected gyroscope and
+	// accelerometer responses in raw counts.
+	factoryTrim func(ctx context.Context, chip *mpu6050) (gyro, accel [3]float64, err error)
+	// An axis passes if its response deviates from the factory trim by a percentage within these
+	// limits.
+	gyroLimits, accelLimits [2]float64
+}
+
+var (
+	// The MPU-6050 runs its self-test with the accelerometer at +/- 8g, and an axis must be within
+	// 14% of its factory trim.
+	mpu6050SelfTest = selfTestSpec{
+		accelRangeBits: accelRanges[8] << 3,
+		factoryTrim:    mpu6050FactoryTrim,
+		gyroLimits:     [2]float64{-14, 14},
+		accelLimits:    [2]float64{-14, 14},
+	}
+	// The MPU-6500 runs its self-test with the accelerometer at +/- 2g. A gyroscope axis must reach
+	// half its factory trim, and an accelerometer axis must be within 50% of it.
+	mpu6500SelfTest = selfTestSpec{
+		accelRangeBits: accelRanges[2] << 3,
+		factoryTrim:    mpu6500FactoryTrim,
+		gyroLimits:     [2]float64{-50, math.Inf(1)},
+		accelLimits:    [2]float64{-50, 50},
+	}
+)
+
+// mpu6050FactoryTrim reads the MPU-6050's trim from SELF_TEST_X through SELF_TEST_A.
+func mpu6050FactoryTrim(ctx context.Context, chip *mpu6050) (gyro, accel [3]float64, err error) {
+	trim, err := chip.readBlock(ctx, selfTestXRegister, 4)
+	if err != nil {
+		return gyro, accel, err
+	}
+	for axis := range 3 {
+		// The accelerometer trim is split across SELF_TEST_[XYZ] and SELF_TEST_A.
+		accelTest := int((trim[axis]>>3)&0x1C) | int(trim[3]>>(4-2*axis))&0x03
+		gyroTest := int(trim[axis] & 0x1F)
+
+		gyro[axis] = gyroFactoryTrim(gyroTest)
+		if axis == 1 {
+			// The Y axis of the gyroscope has the opposite sign.
+			gyro[axis] = -gyro[axis]
+		}
+		accel[axis] = accelFactoryTrim(accelTest)
+	}
+	return gyro, accel, nil
+}
+
+// mpu6500FactoryTrim reads the MPU-6500's trim, which has a whole register per axis: three for the
+// gyroscope starting at 0, and three for the accelerometer starting at 13.
+func mpu6500FactoryTrim(ctx context.Context, chip *mpu6050) (gyro, accel [3]float64, err error) {
+	gyroCodes, err := chip.readBlock(ctx, selfTestGyro6500Register, 3)
+	if err != nil {
+		return gyro, accel, err
+	}
+	accelCodes, err := chip.readBlock(ctx, selfTestAccel6500Register, 3)
+	if err != nil {
+		return gyro, accel, err
+	}
+	for axis := range 3 {
+		gyro[axis] = mpu6500FactoryTrimCode(gyroCodes[axis])
+		accel[axis] = mpu6500FactoryTrimCode(accelCodes[axis])
+	}
+	return gyro, accel, nil
+}
+
+// mpu6500FactoryTrimCode converts an MPU-6500 trim code into the expected self-test response at
+// the smallest full-scale range, which is the same formula for both sensors.
+func mpu6500FactoryTrimCode(code byte) float64 {
+	if code == 0 {
+		return 0
+	}
+	return 2620 * math.Pow(1.01, float64(code)-1)
+}
+
+// averageSelfTestOutputs writes the two config registers, lets the outputs settle, and returns the
+// mean raw accelerometer (indices 0-2) and gyroscope (indices 3-5) counts.
+func (mpu *mpu6050) averageSelfTestOutputs(ctx context.Context, gyroConfig, accelConfig byte) ([6]float64, error) {
+	var sums [6]float64
+	if err := mpu.writeByte(ctx, gyroConfigRegister, gyroConfig); err != nil {
+		return sums, err
+	}
+	if err := mpu.writeByte(ctx, accelConfigRegister, accelConfig); err != nil {
+		return sums, err
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	for range selfTestSamples {
+		rawData, err := mpu.readBlock(ctx, dataRegister, 14)
+		if err != nil {
+			return sums, err
+		}
+		for i := range 3 {
+			sums[i] += float64(utils.Int16FromBytesBE(rawData[2*i : 2*i+2]))
+			sums[i+3] += float64(utils.Int16FromBytesBE(rawData[8+2*i : 10+2*i]))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := range sums {
+		sums[i] /= selfTestSamples
+	}
+	return sums, nil
+}
+
+// gyroFactoryTrim converts a 5-bit XG_TEST-style value into the expected self-test response.
+func gyroFactoryTrim(test int) float64 {
+	if test == 0 {
+		return 0
+	}
+	return 25 * 131 * math.Pow(1.046, float64(test-1))
+}
+
+// accelFactoryTrim converts a 5-bit XA_TEST-style value into the expected self-test response.
+func accelFactoryTrim(test int) float64 {
+	if test == 0 {
+		return 0
+	}
+	return 4096 * 0.34 * math.Pow(0.92/0.34, float64(test-1)/(1<<5-2))
+}
+
+func checkSelfTestAxis(factoryTrim, response float64, limits [2]float64) SelfTestAxis {
+	axis := SelfTestAxis{FactoryTrim: factoryTrim, Response: response}
+	if factoryTrim == 0 {
+		// Without a trim value there is nothing to compare against.
+		return axis
+	}
+	axis.DeviationPercent = 100 * (response - factoryTrim) / factoryTrim
+	axis.Passed = axis.DeviationPercent >= limits[0] && axis.DeviationPercent <= limits[1]
+	return axis
+}
@@ -0,0 +1,44 @@
+package mpu6050
+
+import (
+	"context"
+	"testing"
+
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestDumpRegisters(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// Each register holds its own address.
+	var reads [][2]int
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		reads = append(reads, [2]int{int(register), int(numBytes)})
+		values := make([]byte, numBytes)
+		for i := range values {
+			values[i] = register + byte(i)
+		}
+		return values, nil
+	}
+	i2cHandle.CloseFunc = func() error { return nil }
+	bus := &inject.I2C{}
+	bus.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	chip := &mpu6050{transport: newI2CTransport(bus, "", i2cName, expectedDefaultAddress, logger), logger: logger}
+	dump, err := chip.dumpRegisters(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dump, test.ShouldHaveLength, registerCount)
+
+	// FIFO_R_W is skipped, so that the dump doesn't pop the FIFO.
+	test.That(t, reads, test.ShouldResemble, [][2]int{{0, fifoDataRegister}, {defaultAddressRegister, 1}})
+	test.That(t, ConsumedOnRead(fifoDataRegister), test.ShouldBeTrue)
+	test.That(t, dump[fifoDataRegister], test.ShouldEqual, 0)
+	test.That(t, dump[fifoDataRegister-1], test.ShouldEqual, fifoDataRegister-1)
+	test.That(t, dump[defaultAddressRegister], test.ShouldEqual, defaultAddressRegister)
+}
@@ -0,0 +1,338 @@
+package mpu6050
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DoCommand lets you inspect and modify the chip's registers remotely. The command to run is
+// selected by the "command" key:
+//   - "read_register" reads the register given by "register", which can be an address or a
+//     datasheet name such as "PWR_MGMT_1".
+//   - "read_registers" reads "count" registers starting at "start", and decodes each of them.
+//     Both refuse registers that reading consumes, such as FIFO_R_W, unless "force" is true.
+//   - "write_register" writes "value" to "register". Registers that the driver configures itself are
+//     refused unless "force" is true, and read-only registers are always refused.
+//   - "vibration" returns the most recent vibration analysis in full, including the amplitude
+//     spectrum if "include_spectrum" is true.
+//   - "shock_events" returns the recorded shock events and peak statistics, and "reset_shock" clears
+//     them.
+//   - "reset_steps" zeroes the pedometer's step count.
+//   - "tap_events" returns the queued tap and double-tap events, oldest first, and empties the queue.
+//   - "freeze_gyro_bias" stops the online gyroscope bias estimate from changing, "unfreeze_gyro_bias"
+//     lets it change again, and "reset_gyro_bias" zeroes it.
+func (mpu *mpu6050) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	command, ok := cmd["command"].(string)
+	if !ok {
+		return nil, errors.New(`DoCommand requires a "command" string`)
+	}
+
+	switch command {
+	case "read_register":
+		return mpu.readRegisterCommand(ctx, cmd)
+	case "read_registers":
+		return mpu.readRegistersCommand(ctx, cmd)
+	case "write_register":
+		return mpu.writeRegisterCommand(ctx, cmd)
+	case "vibration":
+		return mpu.vibrationCommand(cmd)
+	case "shock_events":
+		return mpu.shockEventsCommand()
+	case "reset_shock":
+		return mpu.resetShockCommand()
+	case "reset_steps":
+		return mpu.resetStepsCommand()
+	case "tap_events":
+		return mpu.tapEventsCommand()
+	case "freeze_gyro_bias":
+		return mpu.gyroBiasCommand(func(be *gyroBiasEstimator) { be.frozen = true })
+	case "unfreeze_gyro_bias":
+		return mpu.gyroBiasCommand(func(be *gyroBiasEstimator) { be.frozen = false })
+	case "reset_gyro_bias":
+		return mpu.gyroBiasCommand((*gyroBiasEstimator).reset)
+	default:
+		return nil, errors.Errorf("unknown command %q", command)
+	}
+}
+
+func describeRegister(register, value byte) map[string]interface{} {
+	name, fields := DecodeRegister(register, value)
+	decoded := make(map[string]interface{}, len(fields))
+	for field, fieldValue := range fields {
+		decoded[field] = fieldValue
+	}
+	return map[string]interface{}{
+		"register": int(register),
+		"name":     name,
+		"value":    int(value),
+		"fields":   decoded,
+	}
+}
+
+func (mpu *mpu6050) readRegisterCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	register, err := parseRegister(cmd["register"])
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConsumedOnRead(cmd, register, 1); err != nil {
+		return nil, err
+	}
+	value, err := mpu.readByte(ctx, register)
+	if err != nil {
+		return nil, err
+	}
+	return describeRegister(register, value), nil
+}
+
+func (mpu *mpu6050) readRegistersCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	start, err := parseRegister(cmd["start"])
+	if err != nil {
+		return nil, err
+	}
+	count := int64(1)
+	if arg, ok := cmd["count"]; ok {
+		if count, err = parseNumber(arg); err != nil {
+			return nil, errors.Wrap(err, "invalid count")
+		}
+	}
+	if count < 1 || int64(start)+count > registerCount {
+		return nil, errors.Errorf("count must be between 1 and %d when starting at register %d",
+			registerCount-int(start), start)
+	}
+	if err := checkConsumedOnRead(cmd, start, int(count)); err != nil {
+		return nil, err
+	}
+
+	values, err := mpu.readBlock(ctx, start, uint8(count))
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]interface{}{}
+	fields := map[string]interface{}{}
+	list := make([]interface{}, 0, len(values))
+	for i, value := range values {
+		register := start + byte(i)
+		name, decoded := DecodeRegister(register, value)
+		byName[name] = int(value)
+		for field, fieldValue := range decoded {
+			fields[field] = fieldValue
+		}
+		list = append(list, int(value))
+	}
+	return map[string]interface{}{
+		"start":     int(start),
+		"values":    list,
+		"registers": byName,
+		"fields":    fields,
+	}, nil
+}
+
+func (mpu *mpu6050) writeRegisterCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	register, err := parseRegister(cmd["register"])
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseNumber(cmd["value"])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid value")
+	}
+	if value < 0 || value > 0xFF {
+		return nil, errors.Errorf("value %d does not fit in a register", value)
+	}
+
+	info := registers[register]
+	name := registerName(register)
+	if info.readOnly {
+		return nil, errors.Errorf("register %s is read-only", name)
+	}
+	if mpu.driverManaged(register) {
+		if force, _ := cmd["force"].(bool); !force {
+			return nil, errors.Errorf(
+				"register %s is configured by the driver; set \"force\" to true to write it anyway", name)
+		}
+		mpu.logger.CWarnf(ctx, "writing %#x to register %s, which the driver manages; readings may be wrong until "+
+			"the component is reconfigured", value, name)
+	}
+
+	if err := mpu.writeByte(ctx, register, byte(value)); err != nil {
+		return nil, err
+	}
+	readBack, err := mpu.readByte(ctx, register)
+	if err != nil {
+		return nil, err
+	}
+	return describeRegister(register, readBack), nil
+}
+
+// checkConsumedOnRead refuses to read count registers starting at start if reading any of them
+// would take data away from the driver, unless cmd sets "force".
+func checkConsumedOnRead(cmd map[string]interface{}, start byte, count int) error {
+	if force, _ := cmd["force"].(bool); force {
+		return nil
+	}
+	for i := 0; i < count; i++ {
+		register := start + byte(i)
+		if ConsumedOnRead(register) {
+			return errors.Errorf(
+				"reading register %s takes data off the chip; set \"force\" to true to read it anyway",
+				registerName(register))
+		}
+	}
+	return nil
+}
+
+// driverManaged returns whether the driver configures register, so writing it from outside would
+// change or break the driver's behavior.
+func (mpu *mpu6050) driverManaged(register byte) bool {
+	switch {
+	case registers[register].managed:
+		return true
+	case mpu.vibration != nil && fifoRegisters[register]:
+		return true
+	case mpu.fastSampling && (register == sampleRateDivRegister || register == configRegister):
+		return true
+	default:
+		// Over SPI, the driver sets I2C_IF_DIS so that the chip stays in SPI mode.
+		return register == userControlRegister && mpu.transport.userControl() != 0
+	}
+}
+
+// fifoRegisters are the registers the driver configures when vibration analysis is enabled.
+var fifoRegisters = map[byte]bool{
+	sampleRateDivRegister: true,
+	configRegister:        true,
+	fifoEnableRegister:    true,
+	userControlRegister:   true,
+}
+
+func (mpu *mpu6050) vibrationCommand(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if mpu.vibration == nil {
+		return nil, errors.New("vibration analysis is not enabled; set the vibration attribute")
+	}
+	mpu.mu.Lock()
+	result, overflows := mpu.vibrationResult, mpu.fifoOverflows
+	mpu.mu.Unlock()
+	if result == nil {
+		return nil, errors.New("no vibration window has been collected yet")
+	}
+
+	dominant := make([]interface{}, 0, len(result.dominant))
+	for _, peak := range result.dominant {
+		dominant = append(dominant, map[string]interface{}{"frequency_hz": peak.frequency, "amplitude_mps2": peak.amplitude})
+	}
+	bands := make(map[string]interface{}, len(result.bandRMS))
+	for i, band := range mpu.vibration.conf.Bands {
+		bands[band.name()] = result.bandRMS[i]
+	}
+	resp := map[string]interface{}{
+		"time":           result.time.Format(time.RFC3339Nano),
+		"sample_rate_hz": vibrationSampleRate,
+		"window_samples": len(mpu.vibration.window),
+		"rms_mps2":       result.rms,
+		"peak_mps2":      result.peak,
+		"dominant":       dominant,
+		"bands_rms_mps2": bands,
+		"fifo_overflows": overflows,
+	}
+	if include, _ := cmd["include_spectrum"].(bool); include {
+		frequencies := make([]interface{}, len(result.frequencies))
+		amplitudes := make([]interface{}, len(result.amplitudes))
+		for i := range result.frequencies {
+			frequencies[i] = result.frequencies[i]
+			amplitudes[i] = result.amplitudes[i]
+		}
+		resp["spectrum"] = map[string]interface{}{"frequencies_hz": frequencies, "amplitudes_mps2": amplitudes}
+	}
+	return resp, nil
+}
+
+var errShockDisabled = errors.New("shock logging is not enabled; set the shock attribute")
+
+func shockStatsMap(stats *shockStats) map[string]interface{} {
+	return map[string]interface{}{
+		"start":            stats.start.Format(time.RFC3339Nano),
+		"samples":          stats.samples,
+		"peak_x_g":         stats.peak.X,
+		"peak_y_g":         stats.peak.Y,
+		"peak_z_g":         stats.peak.Z,
+		"peak_magnitude_g": stats.peakMagnitude,
+		"max_jerk_mps3":    stats.maxJerk,
+	}
+}
+
+func (mpu *mpu6050) shockEventsCommand() (map[string]interface{}, error) {
+	if mpu.shock == nil {
+		return nil, errShockDisabled
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+
+	events := make([]interface{}, 0, len(mpu.shock.events))
+	for _, event := range mpu.shock.events {
+		events = append(events, event.toMap())
+	}
+	resp := map[string]interface{}{
+		"events":       events,
+		"total_events": mpu.shock.totalEvents,
+		"in_progress":  mpu.shock.active != nil,
+		"since_reset":  shockStatsMap(&mpu.shock.sinceReset),
+	}
+	if last := mpu.shock.last; last != nil {
+		window := shockStatsMap(last)
+		window["rms_g"] = last.rms
+		resp["last_window"] = window
+	}
+	return resp, nil
+}
+
+func (mpu *mpu6050) resetShockCommand() (map[string]interface{}, error) {
+	if mpu.shock == nil {
+		return nil, errShockDisabled
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	mpu.shock.reset()
+	return map[string]interface{}{}, nil
+}
+
+func (mpu *mpu6050) resetStepsCommand() (map[string]interface{}, error) {
+	if mpu.pedometer == nil {
+		return nil, errors.New("the pedometer is not enabled; set the pedometer attribute")
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	steps := mpu.pedometer.steps
+	mpu.pedometer.reset()
+	return map[string]interface{}{"steps": steps}, nil
+}
+
+func (mpu *mpu6050) tapEventsCommand() (map[string]interface{}, error) {
+	if mpu.tap == nil {
+		return nil, errors.New("tap detection is not enabled; set the tap attribute")
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+
+	queued := mpu.tap.drain()
+	events := make([]interface{}, 0, len(queued))
+	for _, event := range queued {
+		events = append(events, event.toMap())
+	}
+	dropped := mpu.tap.dropped
+	mpu.tap.dropped = 0
+	return map[string]interface{}{"events": events, "dropped": dropped}, nil
+}
+
+// gyroBiasCommand applies change to the online bias estimator and returns its new state.
+func (mpu *mpu6050) gyroBiasCommand(change func(*gyroBiasEstimator)) (map[string]interface{}, error) {
+	if mpu.gyroBiasEstimator == nil {
+		return nil, errors.New("online gyro bias estimation is not enabled; set the gyro_bias attribute")
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	change(mpu.gyroBiasEstimator)
+	return mpu.gyroBiasEstimator.readings(time.Now()), nil
+}
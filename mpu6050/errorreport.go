@@ -0,0 +1,182 @@
+package mpu6050
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	// The classes of failure that errors are counted under.
+	errorClassNACK        = "nack"
+	errorClassTimeout     = "timeout"
+	errorClassWrongDevice = "wrong_who_am_i"
+	errorClassStuckData   = "stuck_data"
+	errorClassOther       = "other"
+
+	// errorLogInterval is the shortest time between log lines about failed reads. Errors in between
+	// are counted, and summarized in the next line.
+	errorLogInterval = 10 * time.Second
+	// whoAmICheckInterval is how often the worker checks that the chip is still the one it set up.
+	whoAmICheckInterval = time.Second
+	// defaultStuckDataSamples is how many identical samples in a row mean the chip has stopped
+	// updating its data, about 5 seconds' worth. Noise keeps a working chip from repeating itself
+	// for long.
+	defaultStuckDataSamples = 5000
+)
+
+var errorClasses = []string{
+	errorClassNACK, errorClassTimeout, errorClassWrongDevice, errorClassStuckData, errorClassOther,
+}
+
+var errStuckData = errors.New("the MPU6050's data has stopped changing, so it may have stopped sampling")
+
+// wrongDeviceError means that the WHO_AM_I register doesn't hold a value from whoAmIModels, or no
+// longer holds the value the chip had when the driver set it up.
+type wrongDeviceError struct {
+	location string
+	whoAmI   byte
+}
+
+func (e *wrongDeviceError) Error() string {
+	return fmt.Sprintf("unexpected non-MPU6050 device at %s: response '%d'", e.location, e.whoAmI)
+}
+
+// classifyError returns the class of failure that err belongs to. Bus drivers report NACKs and
+// timeouts in different ways, so this goes by the error message when it has to.
+func classifyError(err error) string {
+	var wrongDevice *wrongDeviceError
+	if errors.As(err, &wrongDevice) {
+		return errorClassWrongDevice
+	}
+	if errors.Is(err, errStuckData) {
+		return errorClassStuckData
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return errorClassTimeout
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timed out") || strings.Contains(msg, "timeout"):
+		return errorClassTimeout
+	// Linux I2C drivers report a NACK as EREMOTEIO or ENXIO.
+	case strings.Contains(msg, "remote i/o error") || strings.Contains(msg, "no such device or address") ||
+		strings.Contains(msg, "nack"):
+		return errorClassNACK
+	default:
+		return errorClassOther
+	}
+}
+
+// errorReporter counts failed reads by class, and logs them at most once per errorLogInterval, so
+// that a failing bus doesn't flood the logs with a line per sample.
+type errorReporter struct {
+	logger logging.Logger
+	// counts holds the failures in each class since the sensor started.
+	counts      map[string]int
+	lastSuccess time.Time
+	// pending holds the failures in each class since the last log line, and latest is the most
+	// recent of them.
+	pending map[string]int
+	latest  error
+	lastLog time.Time
+}
+
+func newErrorReporter(logger logging.Logger) *errorReporter {
+	return &errorReporter{logger: logger, counts: map[string]int{}, pending: map[string]int{}}
+}
+
+// failure records a failed read.
+func (er *errorReporter) failure(ctx context.Context, now time.Time, err error) {
+	class := classifyError(err)
+	er.counts[class]++
+	er.pending[class]++
+	er.latest = err
+	if now.Sub(er.lastLog) < errorLogInterval {
+		return
+	}
+	if er.pendingTotal() == 1 {
+		er.logger.CErrorf(ctx, "error reading MPU6050 sensor (%s): '%s'", class, err)
+	} else {
+		er.logger.CErrorf(ctx, "%d errors reading MPU6050 sensor since %s (%s), most recently: '%s'",
+			er.pendingTotal(), er.lastLog.Format(time.RFC3339), er.pendingSummary(), err)
+	}
+	er.clearPending(now)
+}
+
+// success records a successful read. Errors that haven't been logged yet are summarized once it
+// is time for another log line.
+func (er *errorReporter) success(ctx context.Context, now time.Time) {
+	er.lastSuccess = now
+	if er.pendingTotal() == 0 || now.Sub(er.lastLog) < errorLogInterval {
+		return
+	}
+	er.logger.CWarnf(ctx, "%d errors reading MPU6050 sensor since %s (%s), most recently: '%s'; reads are succeeding again",
+		er.pendingTotal(), er.lastLog.Format(time.RFC3339), er.pendingSummary(), er.latest)
+	er.clearPending(now)
+}
+
+func (er *errorReporter) pendingTotal() int {
+	total := 0
+	for _, count := range er.pending {
+		total += count
+	}
+	return total
+}
+
+// pendingSummary lists the pending failures by class, such as "nack: 3, timeout: 1".
+func (er *errorReporter) pendingSummary() string {
+	var parts []string
+	for _, class := range errorClasses {
+		if count := er.pending[class]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", class, count))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (er *errorReporter) clearPending(now time.Time) {
+	er.pending = map[string]int{}
+	er.latest = nil
+	er.lastLog = now
+}
+
+// readings returns the failure counts, and the time of the last successful read once there has
+// been one.
+func (er *errorReporter) readings() map[string]interface{} {
+	readings := map[string]interface{}{}
+	for _, class := range errorClasses {
+		readings["errors_"+class] = er.counts[class]
+	}
+	if !er.lastSuccess.IsZero() {
+		readings["last_success_unix_sec"] = float64(er.lastSuccess.UnixNano()) / float64(time.Second)
+	}
+	return readings
+}
+
+// stuckDetector notices when the chip returns exactly the same sample over and over.
+type stuckDetector struct {
+	// limit is how many repeats mean the data is stuck, or 0 to never call it stuck.
+	limit   int
+	last    []byte
+	repeats int
+}
+
+// update takes a sample, and returns whether the data looks stuck.
+func (sd *stuckDetector) update(data []byte) bool {
+	if sd.limit <= 0 {
+		return false
+	}
+	if sd.last != nil && string(sd.last) == string(data) {
+		sd.repeats++
+		return sd.repeats >= sd.limit
+	}
+	sd.last = append(sd.last[:0], data...)
+	sd.repeats = 0
+	return false
+}
@@ -0,0 +1,140 @@
+package mpu6050
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestClassifyError(t *testing.T) {
+	test.That(t, classifyError(errors.New("read: remote I/O error")), test.ShouldEqual, errorClassNACK)
+	test.That(t, classifyError(errors.New("i2c: no such device or address")), test.ShouldEqual, errorClassNACK)
+	test.That(t, classifyError(errors.Wrap(context.DeadlineExceeded, "read")), test.ShouldEqual, errorClassTimeout)
+	test.That(t, classifyError(errors.New("SPI transfer timed out")), test.ShouldEqual, errorClassTimeout)
+	test.That(t, classifyError(&wrongDeviceError{whoAmI: 0x70}), test.ShouldEqual, errorClassWrongDevice)
+	test.That(t, classifyError(errors.Wrap(errStuckData, "sample")), test.ShouldEqual, errorClassStuckData)
+	test.That(t, classifyError(errors.New("bad file descriptor")), test.ShouldEqual, errorClassOther)
+}
+
+func TestErrorReporter(t *testing.T) {
+	logger, logs := logging.NewObservedTestLogger(t)
+	ctx := context.Background()
+	er := newErrorReporter(logger)
+	start := time.Unix(1000, 0)
+	test.That(t, er.readings(), test.ShouldNotContainKey, "last_success_unix_sec")
+	er.success(ctx, start)
+
+	// 25 seconds of failures at 1kHz log only once every 10 seconds.
+	var now time.Time
+	for i := 1; i <= 25000; i++ {
+		now = start.Add(time.Duration(i) * time.Millisecond)
+		if i%2 == 0 {
+			er.failure(ctx, now, errors.New("remote I/O error"))
+		} else {
+			er.failure(ctx, now, errors.New("timed out"))
+		}
+	}
+	test.That(t, logs.FilterMessageSnippet("reading MPU6050").Len(), test.ShouldEqual, 3)
+	test.That(t, logs.FilterMessageSnippet("nack: ").Len(), test.ShouldEqual, 2)
+
+	// The errors since the last line are summarized once reads succeed, when it is time to log.
+	er.success(ctx, now.Add(time.Millisecond))
+	test.That(t, logs.FilterMessageSnippet("succeeding again").Len(), test.ShouldEqual, 0)
+	er.success(ctx, now.Add(10*time.Second))
+	test.That(t, logs.FilterMessageSnippet("succeeding again").Len(), test.ShouldEqual, 1)
+	er.success(ctx, now.Add(20*time.Second))
+	test.That(t, logs.FilterMessageSnippet("succeeding again").Len(), test.ShouldEqual, 1)
+
+	readings := er.readings()
+	test.That(t, readings["errors_nack"], test.ShouldEqual, 12500)
+	test.That(t, readings["errors_timeout"], test.ShouldEqual, 12500)
+	test.That(t, readings["errors_stuck_data"], test.ShouldEqual, 0)
+	test.That(t, readings["last_success_unix_sec"], test.ShouldAlmostEqual, float64(now.Add(20*time.Second).Unix()))
+}
+
+func TestStuckDetector(t *testing.T) {
+	sd := stuckDetector{limit: (&Config{}).stuckDataSamples()}
+	test.That(t, sd.limit, test.ShouldEqual, defaultStuckDataSamples)
+	sample := []byte{1, 2, 3}
+	for i := 0; i < sd.limit; i++ {
+		test.That(t, sd.update(sample), test.ShouldBeFalse)
+	}
+	test.That(t, sd.update(sample), test.ShouldBeTrue)
+	test.That(t, sd.update([]byte{1, 2, 4}), test.ShouldBeFalse)
+	test.That(t, sd.update(sample), test.ShouldBeFalse)
+
+	// The check can be turned off.
+	sd = stuckDetector{limit: (&Config{StuckDataSamples: -1}).stuckDataSamples()}
+	for i := 0; i < 2*defaultStuckDataSamples; i++ {
+		test.That(t, sd.update(sample), test.ShouldBeFalse)
+	}
+	test.That(t, (&Config{StuckDataSamples: 100}).stuckDataSamples(), test.ShouldEqual, 100)
+}
+
+func TestWorkerErrors(t *testing.T) {
+	logger, logs := logging.NewObservedTestLogger(t)
+	ctx := context.Background()
+
+	// The sensor starts out on an MPU-9250.
+	var whoAmI atomic.Uint32
+	whoAmI.Store(0x71)
+	var failing atomic.Bool
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		if register == defaultAddressRegister {
+			return []byte{byte(whoAmI.Load())}, nil
+		}
+		if failing.Load() {
+			return nil, errors.New("remote I/O error")
+		}
+		return make([]byte, 14), nil
+	}
+	i2cHandle.WriteByteDataFunc = func(ctx context.Context, register, data byte) error { return nil }
+	i2cHandle.CloseFunc = func() error { return nil }
+	bus := &inject.I2C{}
+	bus.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	sensor, err := makeMpu6050(ctx, logger, testName, &Config{I2cBus: i2cName}, bus)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+	mpu := sensor.(*mpu6050)
+	var lastSuccess float64
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		lastSuccess, _ = readings["last_success_unix_sec"].(float64)
+		test.That(tb, lastSuccess, test.ShouldBeGreaterThan, 0)
+	})
+
+	failing.Store(true)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		mpu.mu.Lock()
+		defer mpu.mu.Unlock()
+		test.That(tb, mpu.errorReport.counts[errorClassNACK], test.ShouldBeGreaterThan, 100)
+	})
+	readings, err := sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, readings["last_success_unix_sec"], test.ShouldBeGreaterThanOrEqualTo, lastSuccess)
+	// Hundreds of failures, but only one log line.
+	test.That(t, logs.FilterMessageSnippet("reading MPU6050").Len(), test.ShouldEqual, 1)
+
+	// The worker notices when something else answers at the chip's address, even another chip in
+	// the family.
+	failing.Store(false)
+	whoAmI.Store(0x70)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		mpu.mu.Lock()
+		defer mpu.mu.Unlock()
+		test.That(tb, mpu.errorReport.counts[errorClassWrongDevice], test.ShouldBeGreaterThan, 0)
+	})
+}
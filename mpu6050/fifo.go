@@ -0,0 +1,137 @@
+package mpu6050
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/utils"
+)
+
+const (
+	// Bits in USER_CTRL, FIFO_EN, and INT_STATUS.
+	userControlFifoEnable = 1 << 6
+	userControlFifoReset  = 1 << 2
+	fifoEnableAccel       = 1 << 3
+	intStatusFifoOverflow = 1 << 4
+
+	fifoAccelSampleBytes = 6
+	// The largest multiple of a sample that fits in a single block read.
+	fifoMaxRead = 255 / fifoAccelSampleBytes * fifoAccelSampleBytes
+	// The FIFO holds 1024 bytes, or 170 accelerometer samples. At 1kHz, having the bus scheduler
+	// drain it every 20ms leaves plenty of headroom.
+	fifoDrainInterval = 20 * time.Millisecond
+)
+
+// startFifo sets the sample rate to 1kHz and starts buffering accelerometer samples in the FIFO.
+func (mpu *mpu6050) startFifo(ctx context.Context) error {
+	// With the digital low pass filter off (DLPF_CFG 0), the gyroscope output rate is 8kHz, and
+	// SMPLRT_DIV divides it by 1 + 7 to match the accelerometer's 1kHz.
+	if err := mpu.writeByte(ctx, configRegister, 0); err != nil {
+		return err
+	}
+	if err := mpu.writeByte(ctx, sampleRateDivRegister, 7); err != nil {
+		return err
+	}
+	if err := mpu.writeByte(ctx, fifoEnableRegister, fifoEnableAccel); err != nil {
+		return err
+	}
+	return mpu.resetFifo(ctx)
+}
+
+func (mpu *mpu6050) resetFifo(ctx context.Context) error {
+	userControl := mpu.transport.userControl()
+	if err := mpu.writeByte(ctx, userControlRegister, userControl|userControlFifoReset); err != nil {
+		return err
+	}
+	return mpu.writeByte(ctx, userControlRegister, userControl|userControlFifoEnable)
+}
+
+// drainFifoOnSchedule drains the FIFO from the bus scheduler's goroutine, so that it takes turns
+// with the regular reads and counts towards the bus utilization. handleFifo analyzes the result.
+func (mpu *mpu6050) drainFifoOnSchedule(ctx context.Context) {
+	samples, err := mpu.drainFifo(ctx)
+	mpu.fifoDrainedAt = time.Now()
+	mpu.fifoSamples = append(mpu.fifoSamples, samples...)
+	if err != nil {
+		// A gap would corrupt the spectrum, so the window starts over.
+		mpu.fifoSamples = mpu.fifoSamples[:0]
+		mpu.fifoErr = err
+		if errors.Is(err, errFifoOverflow) {
+			mpu.logger.CWarnf(ctx, "MPU6050 FIFO: %s", err)
+		}
+	}
+}
+
+// handleFifo adds the samples drained since the last call to the window, and analyzes each full
+// window of them.
+func (mpu *mpu6050) handleFifo(ctx context.Context, now time.Time) {
+	if mpu.fifoWindow == nil {
+		mpu.fifoWindow = make([]r3.Vector, 0, len(mpu.vibration.window))
+	}
+	if err := mpu.fifoErr; err != nil {
+		mpu.fifoErr = nil
+		mpu.fifoWindow = mpu.fifoWindow[:0]
+		if !errors.Is(err, errFifoOverflow) {
+			mpu.mu.Lock()
+			mpu.errorReport.failure(ctx, now, errors.Wrap(err, "can't read the FIFO"))
+			mpu.mu.Unlock()
+		}
+	}
+	for _, sample := range mpu.fifoSamples {
+		mpu.fifoWindow = append(mpu.fifoWindow, sample)
+		if len(mpu.fifoWindow) == cap(mpu.fifoWindow) {
+			result := mpu.vibration.analyze(mpu.fifoWindow, vibrationSampleRate, now)
+			mpu.mu.Lock()
+			mpu.vibrationResult = &result
+			mpu.mu.Unlock()
+			mpu.fifoWindow = mpu.fifoWindow[:0]
+		}
+	}
+	mpu.fifoSamples = mpu.fifoSamples[:0]
+}
+
+var errFifoOverflow = errors.New("FIFO overflowed, so samples were lost")
+
+// drainFifo reads every complete sample in the FIFO, in m/sec/sec in the body frame.
+func (mpu *mpu6050) drainFifo(ctx context.Context) ([]r3.Vector, error) {
+	status, err := mpu.readByte(ctx, intStatusRegister)
+	if err != nil {
+		return nil, err
+	}
+	if status&intStatusFifoOverflow != 0 {
+		mpu.mu.Lock()
+		mpu.fifoOverflows++
+		mpu.mu.Unlock()
+		if err := mpu.resetFifo(ctx); err != nil {
+			return nil, err
+		}
+		return nil, errFifoOverflow
+	}
+
+	countBytes, err := mpu.readBlock(ctx, fifoCountRegister, 2)
+	if err != nil {
+		return nil, err
+	}
+	count := int(utils.Int16FromBytesBE(countBytes))
+	count -= count % fifoAccelSampleBytes
+
+	var samples []r3.Vector
+	for count > 0 {
+		length := min(count, fifoMaxRead)
+		data, err := mpu.readBlock(ctx, fifoDataRegister, uint8(length))
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+fifoAccelSampleBytes <= len(data); i += fifoAccelSampleBytes {
+			accel := toLinearAcceleration(data[i:i+fifoAccelSampleBytes], mpu.maxAcceleration).Sub(mpu.accelBias)
+			if mpu.mounting != nil {
+				accel = rotateVector(*mpu.mounting, accel)
+			}
+			samples = append(samples, accel)
+		}
+		count -= length
+	}
+	return samples, nil
+}
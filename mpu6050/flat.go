@@ -0,0 +1,94 @@
+package mpu6050
+
+import (
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// FlatReadings adds the acceleration and angular velocity to Readings as plain numbers, one per
+// axis, in the chosen units. Every key ends in its unit, so that changing the units never mixes
+// values measured in different units under the same key in captured data.
+type FlatReadings struct {
+	AccelUnit string `json:"accel_unit,omitempty"`
+	GyroUnit  string `json:"gyro_unit,omitempty"`
+	// RawCounts adds the values read off the chip, before any scaling or correction, along with the
+	// scale factors that convert them to the chosen units.
+	RawCounts bool `json:"raw_counts,omitempty"`
+}
+
+func (f *FlatReadings) validate() error {
+	if unit := f.accelUnit(); unit != accelUnitMPS2 && unit != accelUnitG {
+		return errors.Errorf("flat_readings.accel_unit must be %q or %q, got %q", accelUnitMPS2, accelUnitG, unit)
+	}
+	if unit := f.gyroUnit(); unit != gyroUnitDPS && unit != gyroUnitRadPS {
+		return errors.Errorf("flat_readings.gyro_unit must be %q or %q, got %q", gyroUnitDPS, gyroUnitRadPS, unit)
+	}
+	return nil
+}
+
+func (f *FlatReadings) accelUnit() string {
+	if f.AccelUnit == "" {
+		return accelUnitMPS2
+	}
+	return f.AccelUnit
+}
+
+func (f *FlatReadings) gyroUnit() string {
+	if f.GyroUnit == "" {
+		return gyroUnitDPS
+	}
+	return f.GyroUnit
+}
+
+// rawSample holds the counts read off the chip for one sample.
+type rawSample struct {
+	accel       [3]int
+	temperature int
+	gyro        [3]int
+}
+
+// parseRawSample splits the 14 bytes of sensor data into counts.
+func parseRawSample(data []byte) rawSample {
+	var raw rawSample
+	for i := range raw.accel {
+		raw.accel[i] = int(utils.Int16FromBytesBE(data[2*i : 2*i+2]))
+		raw.gyro[i] = int(utils.Int16FromBytesBE(data[8+2*i : 10+2*i]))
+	}
+	raw.temperature = int(utils.Int16FromBytesBE(data[6:8]))
+	return raw
+}
+
+// readings returns the flat readings. accel is in m/sec/sec and gyro in degrees per second, and
+// maxAcceleration and maxRotation are the full-scale ranges in the same units.
+func (f *FlatReadings) readings(
+	accel r3.Vector,
+	gyro spatialmath.AngularVelocity,
+	raw rawSample,
+	maxAcceleration, maxRotation float64,
+) map[string]interface{} {
+	accelScale := accelUnitScale(f.accelUnit())
+	gyroScale := gyroUnitScale(f.gyroUnit())
+
+	readings := map[string]interface{}{}
+	accelSuffix := "_" + f.accelUnit()
+	gyroSuffix := "_" + f.gyroUnit()
+	accelValues := [3]float64{accel.X, accel.Y, accel.Z}
+	gyroValues := [3]float64{gyro.X, gyro.Y, gyro.Z}
+	for i, axis := range []string{"x", "y", "z"} {
+		readings["linear_acceleration_"+axis+accelSuffix] = accelValues[i] * accelScale
+		readings["angular_velocity_"+axis+gyroSuffix] = gyroValues[i] * gyroScale
+		if f.RawCounts {
+			readings["raw_accel_"+axis] = raw.accel[i]
+			readings["raw_gyro_"+axis] = raw.gyro[i]
+		}
+	}
+	if f.RawCounts {
+		readings["raw_temperature"] = raw.temperature
+		// The size of one count, so that raw * scale gives the uncorrected value.
+		readings["accel_scale"+accelSuffix] = setScale(1, maxAcceleration) * accelScale
+		readings["gyro_scale"+gyroSuffix] = setScale(1, maxRotation) * gyroScale
+	}
+	return readings
+}
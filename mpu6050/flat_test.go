@@ -0,0 +1,76 @@
+package mpu6050
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestFlatReadingsConfig(t *testing.T) {
+	test.That(t, (&FlatReadings{}).validate(), test.ShouldBeNil)
+	test.That(t, (&FlatReadings{AccelUnit: accelUnitG, GyroUnit: gyroUnitRadPS}).validate(), test.ShouldBeNil)
+	err := (&FlatReadings{AccelUnit: "ft/s^2"}).validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "accel_unit")
+	err = (&FlatReadings{GyroUnit: "rpm"}).validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "gyro_unit")
+}
+
+func TestFlatReadings(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// Half of full scale on Z (1g at the default range), -1 count of temperature, and a quarter of
+	// full scale on gyroscope X (62.5 dps at the default range).
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	mockData[6] = 0xff
+	mockData[7] = 0xff
+	mockData[8] = 32
+
+	conf := &Config{I2cBus: i2cName, FlatReadings: &FlatReadings{}}
+	sensor, err := makeMpu6050(ctx, logger, testName, conf, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["linear_acceleration_z_mps2"], test.ShouldAlmostEqual, 9.81)
+	})
+	readings, err := sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["linear_acceleration_x_mps2"], test.ShouldEqual, 0)
+	test.That(t, readings["angular_velocity_x_dps"], test.ShouldAlmostEqual, 62.5)
+	test.That(t, readings, test.ShouldNotContainKey, "raw_accel_z")
+
+	conf = &Config{
+		I2cBus:       i2cName,
+		GyroRange:    2000,
+		AccelRange:   16,
+		FlatReadings: &FlatReadings{AccelUnit: accelUnitG, GyroUnit: gyroUnitRadPS, RawCounts: true},
+	}
+	sensor, err = makeMpu6050(ctx, logger, testName, conf, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["raw_accel_z"], test.ShouldEqual, 1<<14)
+	})
+	readings, err = sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["linear_acceleration_z_g"], test.ShouldAlmostEqual, 8)
+	test.That(t, readings["angular_velocity_x_radps"], test.ShouldAlmostEqual, 500*math.Pi/180)
+	test.That(t, readings["raw_gyro_x"], test.ShouldEqual, 1<<13)
+	test.That(t, readings["raw_temperature"], test.ShouldEqual, -1)
+	test.That(t, readings["accel_scale_g"], test.ShouldAlmostEqual, 16.0/(1<<15))
+	test.That(t, readings["gyro_scale_radps"], test.ShouldAlmostEqual, 2000*math.Pi/180/(1<<15))
+	// Raw counts times the scale give back the values.
+	test.That(t, float64(readings["raw_accel_z"].(int))*readings["accel_scale_g"].(float64),
+		test.ShouldAlmostEqual, readings["linear_acceleration_z_g"])
+}
@@ -0,0 +1,123 @@
+package mpu6050
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+const (
+	gravity = 9.81 // m/sec/sec
+
+	// Gains for the orientation filter. The proportional gain sets how quickly the accelerometer
+	// pulls the estimate back to level; the integral gain sets how quickly gyroscope bias is learned.
+	defaultFilterKp = 1.0
+	defaultFilterKi = 0.01
+
+	// The accelerometer only measures gravity when the sensor isn't otherwise accelerating, so we
+	// ignore it as a reference when its magnitude is far from 1g.
+	accelReferenceTolerance = 0.15 * gravity
+
+	// Weight given to each new accelerometer correction in the running tilt variance.
+	tiltVarianceSmoothing = 0.01
+)
+
+// orientationFilter is a Mahony complementary filter: it integrates the gyroscope and corrects the
+// resulting drift in roll and pitch using the direction of gravity seen by the accelerometer. With
+// no magnetometer, yaw is relative to the orientation at startup and drifts slowly.
+type orientationFilter struct {
+	kp, ki float64
+
+	// q rotates vectors from the sensor frame into the world frame, where Z points up.
+	q           quat.Number
+	initialized bool
+	// integral is the integral feedback term, which converges to the negative of the gyroscope
+	// bias, in radians per second.
+	integral r3.Vector
+	// lastError is the angle, in radians, between the measured and predicted gravity directions on
+	// the most recent update that used the accelerometer.
+	lastError float64
+	// tiltVariance is a running average of lastError squared, which we use as the variance of the
+	// roll and pitch estimates, in radians squared.
+	tiltVariance float64
+	// yawVariance accumulates integrated gyroscope noise, in radians squared. Nothing corrects yaw,
+	// so it only grows.
+	yawVariance float64
+}
+
+func newOrientationFilter() *orientationFilter {
+	return &orientationFilter{kp: defaultFilterKp, ki: defaultFilterKi, q: quat.Number{Real: 1}}
+}
+
+// update advances the filter by dt seconds. accel is in m/sec/sec and gyro in radians per second,
+// both in the sensor frame.
+func (f *orientationFilter) update(accel, gyro r3.Vector, dt float64) {
+	if !f.initialized {
+		if accel.Norm() == 0 {
+			return
+		}
+		f.q = levelQuaternion(accel)
+		f.initialized = true
+		return
+	}
+
+	if math.Abs(accel.Norm()-gravity) < accelReferenceTolerance {
+		// The error is the rotation that would bring the predicted "up" onto the measured one.
+		measured := accel.Normalize()
+		predicted := rotateToSensor(f.q, r3.Vector{Z: 1})
+		errorAxis := measured.Cross(predicted)
+		f.lastError = math.Asin(math.Min(errorAxis.Norm(), 1))
+		f.tiltVariance += tiltVarianceSmoothing * (f.lastError*f.lastError - f.tiltVariance)
+
+		if f.ki > 0 {
+			f.integral = f.integral.Add(errorAxis.Mul(f.ki * dt))
+		}
+		gyro = gyro.Add(errorAxis.Mul(f.kp)).Add(f.integral)
+	}
+
+	// dq/dt = 1/2 * q * omega
+	omega := quat.Number{Imag: gyro.X, Jmag: gyro.Y, Kmag: gyro.Z}
+	f.q = normalizeQuaternion(quat.Add(f.q, quat.Scale(0.5*dt, quat.Mul(f.q, omega))))
+}
+
+// addGyroNoise grows the yaw uncertainty by one sample of gyroscope white noise, given its variance
+// in radians per second squared.
+func (f *orientationFilter) addGyroNoise(variance, dt float64) {
+	f.yawVariance += variance * dt * dt
+}
+
+// gravity returns the gravity vector as the accelerometer sees it in the sensor frame, in
+// m/sec/sec. An accelerometer at rest reads +1g pointing up, so this points up as well.
+func (f *orientationFilter) gravity() r3.Vector {
+	return rotateToSensor(f.q, r3.Vector{Z: gravity})
+}
+
+// levelQuaternion returns the orientation whose "up" matches the measured acceleration, with zero
+// yaw.
+func levelQuaternion(accel r3.Vector) quat.Number {
+	roll := math.Atan2(accel.Y, accel.Z)
+	pitch := math.Atan2(-accel.X, math.Hypot(accel.Y, accel.Z))
+	cr, sr := math.Cos(roll/2), math.Sin(roll/2)
+	cp, sp := math.Cos(pitch/2), math.Sin(pitch/2)
+	return quat.Number{Real: cr * cp, Imag: sr * cp, Jmag: cr * sp, Kmag: -sr * sp}
+}
+
+func normalizeQuaternion(q quat.Number) quat.Number {
+	return quat.Scale(1/quat.Abs(q), q)
+}
+
+// rotateToWorld rotates a sensor-frame vector into the world frame.
+func rotateToWorld(q quat.Number, v r3.Vector) r3.Vector {
+	return rotateVector(q, v)
+}
+
+// rotateToSensor rotates a world-frame vector into the sensor frame.
+func rotateToSensor(q quat.Number, v r3.Vector) r3.Vector {
+	return rotateVector(quat.Conj(q), v)
+}
+
+func rotateVector(q quat.Number, v r3.Vector) r3.Vector {
+	rotated := quat.Mul(quat.Mul(q, quat.Number{Imag: v.X, Jmag: v.Y, Kmag: v.Z}), quat.Conj(q))
+	return r3.Vector{X: rotated.Imag, Y: rotated.Jmag, Z: rotated.Kmag}
+}
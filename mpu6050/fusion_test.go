@@ -0,0 +1,64 @@
+package mpu6050
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/test"
+)
+
+func TestOrientationFilterLevelsToGravity(t *testing.T) {
+	filter := newOrientationFilter()
+	// Rolled 30 degrees about X.
+	roll := utils.DegToRad(30)
+	accel := r3.Vector{Y: gravity * math.Sin(roll), Z: gravity * math.Cos(roll)}
+
+	filter.update(accel, r3.Vector{}, 0)
+	test.That(t, filter.initialized, test.ShouldBeTrue)
+	test.That(t, filter.gravity().Sub(accel).Norm(), test.ShouldBeLessThan, 1e-9)
+	test.That(t, rotateToWorld(filter.q, accel).Sub(r3.Vector{Z: gravity}).Norm(), test.ShouldBeLessThan, 1e-9)
+}
+
+func TestOrientationFilterLearnsGyroBias(t *testing.T) {
+	filter := newOrientationFilter()
+	accel := r3.Vector{Z: gravity}
+	bias := r3.Vector{X: 0.01, Y: -0.02}
+
+	// Ten minutes of a level, motionless sensor with a biased gyroscope, sampled at 1 kHz.
+	for range 600000 {
+		filter.update(accel, bias, 0.001)
+	}
+	test.That(t, filter.integral.Add(bias).Norm(), test.ShouldBeLessThan, 1e-3)
+	test.That(t, filter.gravity().Sub(accel).Norm(), test.ShouldBeLessThan, 0.01)
+}
+
+func TestVelocityEstimator(t *testing.T) {
+	filter := newOrientationFilter()
+	estimator := &velocityEstimator{}
+	stillness := newStillnessDetector(Stillness{})
+	still := r3.Vector{Z: gravity}
+	filter.update(still, r3.Vector{}, 0)
+	now := time.Now()
+
+	// One second of 3 m/sec/sec forward acceleration should give 3 m/sec.
+	moving := r3.Vector{X: 3, Z: gravity}
+	for range 1000 {
+		now = now.Add(time.Millisecond)
+		estimator.update(filter.q, moving, stillness.update(now, moving, r3.Vector{}), 0.001)
+	}
+	velocity := estimator.sensorFrameVelocity(filter.q)
+	test.That(t, velocity.X, test.ShouldAlmostEqual, 3.0, 1e-6)
+	test.That(t, velocity.Z, test.ShouldAlmostEqual, 0.0, 1e-6)
+	test.That(t, estimator.stdDev(), test.ShouldBeGreaterThan, 0)
+
+	// Sitting still triggers a zero-velocity update.
+	for range defaultStillnessWindow {
+		now = now.Add(time.Millisecond)
+		estimator.update(filter.q, still, stillness.update(now, still, r3.Vector{}), 0.001)
+	}
+	test.That(t, estimator.sensorFrameVelocity(filter.q), test.ShouldResemble, r3.Vector{})
+	test.That(t, estimator.stdDev(), test.ShouldEqual, 0)
+}
@@ -0,0 +1,52 @@
+package mpu6050
+
+import (
+	"go.viam.com/rdk/utils"
+)
+
+// chipModel holds what differs between the chips in the family. They all share the MPU-6050's
+// sensor data registers, which is all the driver needs to sample them, but the temperature sensor
+// and the self-test differ.
+type chipModel struct {
+	name string
+	// TEMP_OUT converts to degrees Celsius as counts / tempSensitivity + tempOffset.
+	tempSensitivity float64
+	tempOffset      float64
+	// selfTest describes how to run the chip's self-test.
+	selfTest selfTestSpec
+}
+
+var (
+	mpu6050Model = chipModel{
+		name: "MPU-6050/MPU-6000",
+		// Taken straight from the MPU6050 register map. Yes, these are weird constants.
+		tempSensitivity: 340,
+		tempOffset:      36.53,
+		selfTest:        mpu6050SelfTest,
+	}
+	// The MPU-9250 and MPU-9255 contain an MPU-6500, so they read the same way.
+	mpu6500Model = chipModel{
+		name:            "MPU-6500",
+		tempSensitivity: 333.87,
+		tempOffset:      21,
+		selfTest:        mpu6500SelfTest,
+	}
+)
+
+// chipModels holds the chips in the family, keyed by the value their WHO_AM_I register reports.
+var chipModels = map[byte]chipModel{
+	0x68: mpu6050Model,
+	0x70: mpu6500Model,
+	0x71: withName(mpu6500Model, "MPU-9250"),
+	0x73: withName(mpu6500Model, "MPU-9255"),
+}
+
+func withName(model chipModel, name string) chipModel {
+	model.name = name
+	return model
+}
+
+// temperature takes the 2 bytes of TEMP_OUT and gives back degrees Celsius.
+func (model chipModel) temperature(data []byte) float64 {
+	return float64(utils.Int16FromBytesBE(data))/model.tempSensitivity + model.tempOffset
+}
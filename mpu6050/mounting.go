@@ -0,0 +1,124 @@
+package mpu6050
+
+import (
+	"math"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// Mounting describes how the chip is mounted on the body it measures. Every vector and orientation
+// the driver reports is rotated from the chip's axes into the body's axes. Set exactly one of the
+// fields.
+type Mounting struct {
+	// Axes names the chip axis that lines up with each of the body's X, Y, and Z axes, with an
+	// optional sign: ["x", "-y", "-z"] is a chip mounted upside-down.
+	Axes []string `json:"axes,omitempty"`
+	// Quaternion and EulerDegrees give the orientation of the chip relative to the body.
+	Quaternion   *MountingQuaternion `json:"quaternion,omitempty"`
+	EulerDegrees *MountingEuler      `json:"euler_degrees,omitempty"`
+}
+
+// MountingQuaternion is a rotation given as a quaternion. It need not be normalized.
+type MountingQuaternion struct {
+	W float64 `json:"w"`
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// MountingEuler is a rotation given as roll, pitch, and yaw in degrees, applied in yaw, pitch, roll
+// order.
+type MountingEuler struct {
+	Roll  float64 `json:"roll"`
+	Pitch float64 `json:"pitch"`
+	Yaw   float64 `json:"yaw"`
+}
+
+var axisVectors = map[string]r3.Vector{"x": {X: 1}, "y": {Y: 1}, "z": {Z: 1}}
+
+// rotation returns the quaternion that rotates vectors from the chip's frame into the body's.
+func (m *Mounting) rotation() (quat.Number, error) {
+	set := 0
+	if m.Axes != nil {
+		set++
+	}
+	if m.Quaternion != nil {
+		set++
+	}
+	if m.EulerDegrees != nil {
+		set++
+	}
+	if set != 1 {
+		return quat.Number{}, errors.New("mounting must set exactly one of axes, quaternion, or euler_degrees")
+	}
+
+	switch {
+	case m.Quaternion != nil:
+		q := quat.Number{Real: m.Quaternion.W, Imag: m.Quaternion.X, Jmag: m.Quaternion.Y, Kmag: m.Quaternion.Z}
+		if quat.Abs(q) == 0 {
+			return quat.Number{}, errors.New("mounting quaternion must not be zero")
+		}
+		return normalizeQuaternion(q), nil
+	case m.EulerDegrees != nil:
+		euler := spatialmath.EulerAngles{
+			Roll:  utils.DegToRad(m.EulerDegrees.Roll),
+			Pitch: utils.DegToRad(m.EulerDegrees.Pitch),
+			Yaw:   utils.DegToRad(m.EulerDegrees.Yaw),
+		}
+		return euler.Quaternion(), nil
+	default:
+		return axesRotation(m.Axes)
+	}
+}
+
+// axesRotation turns an axis remapping into a rotation, rejecting remappings that would mirror the
+// data instead of rotating it.
+func axesRotation(axes []string) (quat.Number, error) {
+	if len(axes) != 3 {
+		return quat.Number{}, errors.Errorf("mounting axes must have 3 entries, got %d", len(axes))
+	}
+	var rows [3]r3.Vector
+	for i, axis := range axes {
+		name := strings.ToLower(strings.TrimSpace(axis))
+		sign := 1.0
+		if strings.HasPrefix(name, "-") {
+			sign = -1
+		}
+		name = strings.TrimLeft(name, "+-")
+		vector, ok := axisVectors[name]
+		if !ok {
+			return quat.Number{}, errors.Errorf("unknown mounting axis %q", axis)
+		}
+		rows[i] = vector.Mul(sign)
+	}
+	if det := rows[0].Dot(rows[1].Cross(rows[2])); math.Abs(det-1) > 1e-9 {
+		return quat.Number{}, errors.Errorf("mounting axes %v do not describe a rotation: each chip axis must "+
+			"appear once, and an odd number of sign flips mirrors the data", axes)
+	}
+	return matrixToQuaternion(rows), nil
+}
+
+// matrixToQuaternion converts a rotation matrix, given by its rows, into a quaternion.
+func matrixToQuaternion(m [3]r3.Vector) quat.Number {
+	var q quat.Number
+	switch trace := m[0].X + m[1].Y + m[2].Z; {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = quat.Number{Real: s / 4, Imag: (m[2].Y - m[1].Z) / s, Jmag: (m[0].Z - m[2].X) / s, Kmag: (m[1].X - m[0].Y) / s}
+	case m[0].X > m[1].Y && m[0].X > m[2].Z:
+		s := 2 * math.Sqrt(1+m[0].X-m[1].Y-m[2].Z)
+		q = quat.Number{Real: (m[2].Y - m[1].Z) / s, Imag: s / 4, Jmag: (m[0].Y + m[1].X) / s, Kmag: (m[0].Z + m[2].X) / s}
+	case m[1].Y > m[2].Z:
+		s := 2 * math.Sqrt(1+m[1].Y-m[0].X-m[2].Z)
+		q = quat.Number{Real: (m[0].Z - m[2].X) / s, Imag: (m[0].Y + m[1].X) / s, Jmag: s / 4, Kmag: (m[1].Z + m[2].Y) / s}
+	default:
+		s := 2 * math.Sqrt(1+m[2].Z-m[0].X-m[1].Y)
+		q = quat.Number{Real: (m[1].X - m[0].Y) / s, Imag: (m[0].Z + m[2].X) / s, Jmag: (m[1].Z + m[2].Y) / s, Kmag: s / 4}
+	}
+	return normalizeQuaternion(q)
+}
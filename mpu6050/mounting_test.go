@@ -0,0 +1,57 @@
+package mpu6050
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestMountingAxes(t *testing.T) {
+	v := r3.Vector{X: 1, Y: 2, Z: 3}
+	for _, tc := range []struct {
+		axes     []string
+		expected r3.Vector
+	}{
+		{[]string{"x", "y", "z"}, r3.Vector{X: 1, Y: 2, Z: 3}},
+		{[]string{"x", "-y", "-z"}, r3.Vector{X: 1, Y: -2, Z: -3}},
+		{[]string{"y", "-x", "z"}, r3.Vector{X: 2, Y: -1, Z: 3}},
+		{[]string{"-y", "-x", "-z"}, r3.Vector{X: -2, Y: -1, Z: -3}},
+		{[]string{"z", "x", "y"}, r3.Vector{X: 3, Y: 1, Z: 2}},
+		{[]string{"+Z", " -Y", "x"}, r3.Vector{X: 3, Y: -2, Z: 1}},
+	} {
+		q, err := (&Mounting{Axes: tc.axes}).rotation()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, rotateVector(q, v).Sub(tc.expected).Norm(), test.ShouldBeLessThan, 1e-9)
+	}
+
+	for _, axes := range [][]string{
+		{"x", "y"},
+		{"x", "x", "z"},
+		{"x", "y", "-z"},
+		{"x", "y", "w"},
+	} {
+		_, err := (&Mounting{Axes: axes}).rotation()
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestMountingRotations(t *testing.T) {
+	v := r3.Vector{X: 1, Y: 2, Z: 3}
+	upsideDown := r3.Vector{X: 1, Y: -2, Z: -3}
+
+	q, err := (&Mounting{EulerDegrees: &MountingEuler{Roll: 180}}).rotation()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rotateVector(q, v).Sub(upsideDown).Norm(), test.ShouldBeLessThan, 1e-9)
+
+	q, err = (&Mounting{Quaternion: &MountingQuaternion{X: 2}}).rotation()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rotateVector(q, v).Sub(upsideDown).Norm(), test.ShouldBeLessThan, 1e-9)
+
+	_, err = (&Mounting{Quaternion: &MountingQuaternion{}}).rotation()
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = (&Mounting{}).rotation()
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = (&Mounting{Axes: []string{"x", "y", "z"}, EulerDegrees: &MountingEuler{}}).rotation()
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -1,12 +1,11 @@
-//go:build linux
-
 // Package mpu6050 implements the movementsensor interface for an MPU-6050 6-axis accelerometer. A
 // datasheet for this chip is at
 // https://components101.com/sites/default/files/component_datasheet/MPU6050-DataSheet.pdf and a
 // description of the I2C registers is at
 // https://download.datasheets.com/pdfs/2015/3/19/8/3/59/59/invse_/manual/5rm-mpu-6000a-00v4.2.pdf
 //
-// We support reading the accelerometer, gyroscope, and thermometer data off of the chip. We do not
+// We support reading the accelerometer, gyroscope, and thermometer data off of the chip, and can
+// optionally fuse them into orientation and short-horizon linear velocity estimates. We do not
 // yet support using the digital interrupt pin to notify on events (freefall, collision, etc.),
 // nor do we yet support using the secondary I2C connection to add an external clock or
 // magnetometer.
@@ -18,11 +17,15 @@
 //
 // If you use the alternate address, your config file for this component must set its
 // "use_alternate_i2c_address" boolean to true.
+//
+// The MPU-6000 has the same registers, and can also be wired to an SPI bus instead; set "spi" in
+// the config to use it that way.
 package mpu6050
 
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -35,43 +38,141 @@ import (
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils"
-	goutils "go.viam.com/utils"
+	"gonum.org/v1/gonum/num/quat"
 )
 
 const (
-	defaultAddressRegister = 117
 	expectedDefaultAddress = 0x68
 	alternateAddress       = 0x69
-
-	powerRegister          = 107
 )
 
 type mpu6050 struct {
 	resource.Named
 	resource.AlwaysRebuild
-	bus        buses.I2C
-	i2cAddress byte
-	mu         sync.Mutex
+	transport transport
+	mu        sync.Mutex
+
+	// Full-scale ranges and calibration offsets, fixed at construction time.
+	maxRotation     float64                     // degrees per second
+	maxAcceleration float64                     // m/sec/sec
+	gyroBias        spatialmath.AngularVelocity // degrees per second
+	accelBias       r3.Vector                   // m/sec/sec
+	tempComp        *TemperatureCompensation
+	// mounting rotates samples from the chip's frame into the body's, or is nil if the chip's axes
+	// are the body's axes.
+	mounting *quat.Number
 
 	// The 3 things we can measure: lock the mutex before reading or writing these.
-	angularVelocity    spatialmath.AngularVelocity
-	temperature        float64
-	linearAcceleration r3.Vector
+	angularVelocity    spatialmath.AngularVelocity // degrees per second
+	temperature        float64                     // degrees Celsius
+	linearAcceleration r3.Vector                   // m/sec/sec
+	// angularVelocityUnit is the unit AngularVelocity and Readings report angular velocity in,
+	// fixed at construction time.
+	angularVelocityUnit string
+	// Results of the optional estimators below, also protected by the mutex. gravity is in the
+	// sensor frame.
+	orientation    quat.Number
+	gravity        r3.Vector
+	linearVelocity r3.Vector
+	velocityStdDev float64
+	// Noise and uncertainty estimates reported by Accuracy, also protected by the mutex.
+	noiseReady      bool
+	accelNoise      r3.Vector // m/sec/sec
+	gyroNoise       r3.Vector // degrees per second
+	tiltUncertainty float64   // radians
+	yawUncertainty  float64   // radians
+	// The inclinometer outputs, and the stillness detector's state.
+	tiltAngles  tiltAngles
+	still       bool
+	motionState string
+	timeInState time.Duration
+	// The most recent vibration window, and how many times the FIFO has overflowed.
+	vibrationResult *vibrationResult
+	fifoOverflows   int
 	// Stores the most recent error from the background goroutine
 	err movementsensor.LastError
-
-	workers *goutils.StoppableWorkers
-	logger  logging.Logger
+	// errorReport counts and logs the background goroutines' failures, also protected by the mutex.
+	errorReport *errorReporter
+
+	// Which gravity-compensated values to report, fixed at construction time.
+	removeGravity          bool
+	reportUserAcceleration bool
+	reportWorldFrame       bool
+
+	// Optional estimators, which are nil unless the config enables them. Only the background
+	// goroutine touches these.
+	filter    *orientationFilter
+	velocity  *velocityEstimator
+	tilt      *tiltEstimator
+	vibration *vibrationAnalyzer
+	stillness *stillnessDetector
+	// onlineGyroBias is the most recent online bias estimate, in degrees per second, which the
+	// worker subtracts from the next sample.
+	onlineGyroBias r3.Vector
+	noise          noiseEstimator
+	lastSampleTime time.Time
+	// whoAmI is the chip's WHO_AM_I value, and model is the chip it identifies. Neither changes
+	// after construction.
+	whoAmI byte
+	model  chipModel
+	// whoAmICheckedAt is when the scheduler last confirmed the chip's identity, and stuck watches
+	// for data that has stopped changing. Only the scheduler's goroutine touches these.
+	whoAmICheckedAt time.Time
+	stuck           stuckDetector
+	// The scheduler drains the FIFO along with the regular samples when vibration analysis is on.
+	// fifoDrainedAt is when it last did, fifoSamples and fifoErr are what readSample got for
+	// handleSample to analyze, and fifoWindow collects samples until there is a full window. Only
+	// the scheduler's goroutine touches these.
+	fifoDrainedAt time.Time
+	fifoSamples   []r3.Vector
+	fifoErr       error
+	fifoWindow    []r3.Vector
+	// shock, pedometer, and tap are updated by the worker and read by DoCommand, so they are
+	// protected by the mutex.
+	shock     *shockMonitor
+	pedometer *pedometer
+	tap       *tapDetector
+	// gyroBiasEstimator is also protected by the mutex, because DoCommand can freeze and reset it.
+	gyroBiasEstimator *gyroBiasEstimator
+
+	// busUtilization is the fraction of the time that reading samples keeps the bus busy, and
+	// sampledAt is when the most recent sample was taken. Both are protected by the mutex.
+	busUtilization float64
+	sampledAt      time.Time
+	// flatReadings is fixed at construction time, and rawCounts holds the latest sample's counts
+	// for it, protected by the mutex.
+	flatReadings *FlatReadings
+	rawCounts    rawSample
+	// batch collects samples between Readings calls when batching is on, and is also protected by
+	// the mutex.
+	batch *sampleBatch
+	// chipKey identifies the chip, so that a temperature sensor on the same chip can find us.
+	chipKey string
+	// fastSampling is set when the chip is sampled faster than 1kHz, so the driver manages its
+	// sample rate.
+	fastSampling bool
+
+	logger logging.Logger
 }
 
-func addressReadError(err error, address byte, bus string) error {
-	msg := fmt.Sprintf("can't read from I2C address %d on bus %s", address, bus)
+func readError(err error, tr transport) error {
+	msg := fmt.Sprintf("can't read from %s", tr)
 	return errors.Wrap(err, msg)
 }
 
-func unexpectedDeviceError(address, defaultAddress byte) error {
-	return errors.Errorf("unexpected non-MPU6050 device at address %d: response '%d'",
-		address, defaultAddress)
+func unexpectedDeviceError(tr transport, defaultAddress byte) error {
+	return &wrongDeviceError{location: tr.String(), whoAmI: defaultAddress}
+}
+
+// address returns the I2C address selected by the config.
+func (conf *Config) address() byte {
+	if conf.I2cAddress != 0 {
+		return byte(conf.I2cAddress)
+	}
+	if conf.UseAlternateI2CAddress {
+		return alternateAddress
+	}
+	return expectedDefaultAddress
 }
 
 // NewMpu6050 constructs a new Mpu6050 object.
@@ -82,18 +183,32 @@ func NewMpu6050(
 	busName string,
 	useAlternateI2CAddress bool,
 ) (movementsensor.MovementSensor, error) {
-	bus, err := buses.NewI2cBus(busName)
+	conf := &Config{I2cBus: busName, UseAlternateI2CAddress: useAlternateI2CAddress}
+	return NewMpu6050FromConfig(ctx, logger, name, conf)
+}
+
+// NewMpu6050FromConfig constructs a new Mpu6050 object using every option available in the
+// component config.
+func NewMpu6050FromConfig(
+	ctx context.Context,
+	logger logging.Logger,
+	name string,
+	conf *Config,
+) (movementsensor.MovementSensor, error) {
+	if _, err := conf.Validate(""); err != nil {
+		return nil, err
+	}
+	tr, err := conf.newTransport(nil, logger)
 	if err != nil {
 		return nil, err
 	}
-	return makeMpu6050(ctx, logger, movementsensor.Named(name), busName, bus, useAlternateI2CAddress)
+	return makeMpu6050OnTransport(ctx, logger, movementsensor.Named(name), conf, tr)
 }
 
-
 // newMpu6050 constructs a new Mpu6050 object.
 func newMpu6050(
 	ctx context.Context,
-	_ resource.Dependencies,
+	deps resource.Dependencies,
 	conf resource.Config,
 	logger logging.Logger,
 ) (movementsensor.MovementSensor, error) {
@@ -102,11 +217,11 @@ func newMpu6050(
 		return nil, err
 	}
 
-	bus, err := buses.NewI2cBus(newConf.I2cBus)
+	tr, err := newConf.newTransport(deps, logger)
 	if err != nil {
 		return nil, err
 	}
-	return makeMpu6050(ctx, logger, conf.ResourceName(), newConf.I2cBus, bus, newConf.UseAlternateI2CAddress)
+	return makeMpu6050OnTransport(ctx, logger, conf.ResourceName(), newConf, tr)
 }
 
 // This function is separated from NewMpu6050 solely so you can inject a mock I2C bus in tests.
@@ -114,37 +229,105 @@ func makeMpu6050(
 	ctx context.Context,
 	logger logging.Logger,
 	name resource.Name,
-	busName string,
+	conf *Config,
 	bus buses.I2C,
-	useAlternateI2CAddress bool,
 ) (movementsensor.MovementSensor, error) {
-	var address byte
-	if useAlternateI2CAddress {
-		address = alternateAddress
-	} else {
-		address = expectedDefaultAddress
-	}
-	logger.CDebugf(ctx, "Using address %d for MPU6050 sensor", address)
+	tr := newI2CTransport(bus, conf.Board, conf.I2cBus, conf.address(), logger)
+	return makeMpu6050OnTransport(ctx, logger, name, conf, tr)
+}
+
+// makeMpu6050OnTransport sets up the chip over whichever bus it is wired to, and starts reading
+// from it.
+func makeMpu6050OnTransport(
+	ctx context.Context,
+	logger logging.Logger,
+	name resource.Name,
+	conf *Config,
+	tr transport,
+) (movementsensor.MovementSensor, error) {
+	logger.CDebugf(ctx, "Using %s for MPU6050 sensor", tr)
 
 	sensor := &mpu6050{
-		Named:      name.AsNamed(),
-		bus:        bus,
-		i2cAddress: address,
-		logger:     logger,
+		Named:           name.AsNamed(),
+		transport:       tr,
+		chipKey:         chipKey(tr),
+		maxRotation:     float64(conf.gyroRange()),
+		maxAcceleration: float64(conf.accelRange()) * 9.81, /* m/sec/sec */
+		logger:          logger,
 		// On overloaded boards, the I2C bus can become flaky. Only report errors if at least 5 of
 		// the last 10 attempts to talk to the device have failed.
-		err: movementsensor.NewLastError(10, 5),
+		err:                    movementsensor.NewLastError(10, 5),
+		errorReport:            newErrorReporter(logger),
+		stuck:                  stuckDetector{limit: conf.stuckDataSamples()},
+		orientation:            quat.Number{Real: 1},
+		removeGravity:          conf.RemoveGravity,
+		reportUserAcceleration: conf.ReportUserAcceleration,
+		reportWorldFrame:       conf.ReportWorldFrame,
+		tempComp:               conf.TemperatureCompensation,
+		flatReadings:           conf.FlatReadings,
+		angularVelocityUnit:    conf.angularVelocityUnit(),
+	}
+	if conf.needsOrientation() {
+		sensor.filter = newOrientationFilter()
+	}
+	if conf.EstimateLinearVelocity {
+		sensor.velocity = &velocityEstimator{}
+	}
+	if conf.Vibration != nil {
+		sensor.vibration = newVibrationAnalyzer(*conf.Vibration)
+	}
+	if conf.Shock != nil {
+		sensor.shock = newShockMonitor(*conf.Shock)
+	}
+	if conf.Tilt != nil {
+		sensor.tilt = newTiltEstimator(*conf.Tilt)
+	}
+	stillness := Stillness{}
+	if conf.Stillness != nil {
+		stillness = *conf.Stillness
+	}
+	sensor.stillness = newStillnessDetector(stillness)
+	if gyroBias := conf.gyroBias(); gyroBias != nil {
+		sensor.gyroBiasEstimator = newGyroBiasEstimator(*gyroBias)
+	}
+	if conf.Pedometer != nil {
+		sensor.pedometer = newPedometer(*conf.Pedometer)
+	}
+	if conf.Tap != nil {
+		sensor.tap = newTapDetector(*conf.Tap)
+	}
+	if conf.Batch != nil {
+		sensor.batch = newSampleBatch(*conf.Batch)
+	}
+	if conf.Mounting != nil {
+		// This was already checked in Validate.
+		mounting, err := conf.Mounting.rotation()
+		if err != nil {
+			return nil, err
+		}
+		sensor.mounting = &mounting
+	}
+	if conf.Calibration != nil {
+		if bias := conf.Calibration.GyroBias; bias != nil {
+			sensor.gyroBias = spatialmath.AngularVelocity{X: bias[0], Y: bias[1], Z: bias[2]}
+		}
+		if bias := conf.Calibration.AccelBias; bias != nil {
+			sensor.accelBias = r3.Vector{X: bias[0], Y: bias[1], Z: bias[2]}
+		}
 	}
 
 	// To check that we're able to talk to the chip, we should be able to read register 117 and get
-	// back the device's non-alternative address (0x68)
+	// back one of the values in chipModels (0x68 on the MPU-6050).
 	defaultAddress, err := sensor.readByte(ctx, defaultAddressRegister)
 	if err != nil {
-		return nil, addressReadError(err, address, busName)
+		return nil, readError(err, tr)
 	}
-	if defaultAddress != expectedDefaultAddress {
-		return nil, unexpectedDeviceError(address, defaultAddress)
+	model, ok := chipModels[defaultAddress]
+	if !ok {
+		return nil, unexpectedDeviceError(tr, defaultAddress)
 	}
+	sensor.whoAmI = defaultAddress
+	sensor.model = model
 
 	// The chip starts out in standby mode (the Sleep bit in the power management register defaults
 	// to 1). Set it to measurement mode (by turning off the Sleep bit) so we can get data from it.
@@ -154,84 +337,255 @@ func makeMpu6050(
 	if err != nil {
 		return nil, errors.Errorf("Unable to wake up MPU6050: '%s'", err.Error())
 	}
+	if userControl := tr.userControl(); userControl != 0 {
+		if err := sensor.writeByte(ctx, userControlRegister, userControl); err != nil {
+			return nil, errors.Wrap(err, "unable to configure the MPU6050 interface")
+		}
+	}
 
-	// Now, turn on the background goroutine that constantly reads from the chip and stores data in
-	// the object we created.
-	sensor.workers = goutils.NewBackgroundStoppableWorkers(func(cancelCtx context.Context) {
-		// Reading data a thousand times per second is probably fast enough.
-		timer := time.NewTicker(time.Millisecond)
-		defer timer.Stop()
-
-		for {
-			select {
-			case <-timer.C:
-				rawData, err := sensor.readBlock(cancelCtx, 59, 14)
-				// Record `err` no matter what: even if it's nil, that's useful information.
-				sensor.err.Set(err)
-				if err != nil {
-					sensor.logger.CErrorf(ctx, "error reading MPU6050 sensor: '%s'", err)
-					continue
-				}
-
-				linearAcceleration := toLinearAcceleration(rawData[0:6])
-				// Taken straight from the MPU6050 register map. Yes, these are weird constants.
-				temperature := float64(utils.Int16FromBytesBE(rawData[6:8]))/340.0 + 36.53
-				angularVelocity := toAngularVelocity(rawData[8:14])
-
-				// Lock the mutex before modifying the state within the object. By keeping the mutex
-				// unlocked for everything else, we maximize the time when another thread can read the
-				// values.
-				sensor.mu.Lock()
-				sensor.linearAcceleration = linearAcceleration
-				sensor.temperature = temperature
-				sensor.angularVelocity = angularVelocity
-				sensor.mu.Unlock()
-			case <-cancelCtx.Done():
-				return
-			}
+	// Select the full-scale ranges. FS_SEL and AFS_SEL are bits 3 and 4 of their registers; the
+	// self-test bits around them stay off.
+	if err := sensor.writeByte(ctx, gyroConfigRegister, gyroRanges[conf.gyroRange()]<<3); err != nil {
+		return nil, errors.Wrap(err, "unable to set MPU6050 gyroscope range")
+	}
+	if err := sensor.writeByte(ctx, accelConfigRegister, accelRanges[conf.accelRange()]<<3); err != nil {
+		return nil, errors.Wrap(err, "unable to set MPU6050 accelerometer range")
+	}
+	if sensor.vibration != nil {
+		if err := sensor.startFifo(ctx); err != nil {
+			return nil, errors.Wrap(err, "unable to start the MPU6050 FIFO")
+		}
+	}
+	if conf.sampleRate() > defaultSampleRate {
+		if err := sensor.startFastSampling(ctx); err != nil {
+			return nil, errors.Wrap(err, "unable to set the MPU6050 sample rate")
 		}
-	})
+	}
+
+	// Now, have the bus's scheduler constantly read from the chip and store data in the object we
+	// created. Every sensor on the same bus shares one scheduler, which takes turns between them.
+	if err := scheduleOnBus(tr.busKey(), conf.samplePeriod(), sensor); err != nil {
+		return nil, err
+	}
+	attachMovementSensor(sensor.chipKey, sensor)
 
 	return sensor, nil
 }
 
-func (mpu *mpu6050) readByte(ctx context.Context, register byte) (byte, error) {
-	result, err := mpu.readBlock(ctx, register, 1)
-	if err != nil {
-		return 0, err
+// startFastSampling has the chip update its sensor data at the gyroscope's full 8kHz, by turning
+// off the digital low pass filter (DLPF_CFG 0) and leaving the sample rate undivided. The
+// accelerometer still only updates at 1kHz.
+func (mpu *mpu6050) startFastSampling(ctx context.Context) error {
+	if err := mpu.writeByte(ctx, configRegister, 0); err != nil {
+		return err
 	}
-	return result[0], err
+	if err := mpu.writeByte(ctx, sampleRateDivRegister, 0); err != nil {
+		return err
+	}
+	mpu.fastSampling = true
+	return nil
 }
 
-func (mpu *mpu6050) readBlock(ctx context.Context, register byte, length uint8) ([]byte, error) {
-	handle, err := mpu.bus.OpenHandle(mpu.i2cAddress)
+func (mpu *mpu6050) readSample(ctx context.Context) ([]byte, error) {
+	// If the chip resets or something else answers at its address, the data would be garbage, so
+	// check that it is still there every so often.
+	if time.Since(mpu.whoAmICheckedAt) >= whoAmICheckInterval {
+		whoAmI, err := mpu.readByte(ctx, defaultAddressRegister)
+		if err != nil {
+			return nil, err
+		}
+		if whoAmI != mpu.whoAmI {
+			return nil, unexpectedDeviceError(mpu.transport, whoAmI)
+		}
+		mpu.whoAmICheckedAt = time.Now()
+	}
+	data, err := mpu.readBlock(ctx, dataRegister, 14)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		err := handle.Close()
-		if err != nil {
-			mpu.logger.CError(ctx, err)
+	if mpu.vibration != nil && time.Since(mpu.fifoDrainedAt) >= fifoDrainInterval {
+		mpu.drainFifoOnSchedule(ctx)
+	}
+	return data, nil
+}
+
+func (mpu *mpu6050) handleSample(ctx context.Context, rawData []byte, err error, now time.Time) {
+	if mpu.vibration != nil {
+		mpu.handleFifo(ctx, now)
+	}
+	if err == nil && mpu.stuck.update(rawData) {
+		err = errStuckData
+	}
+	// Record `err` no matter what: even if it's nil, that's useful information.
+	mpu.err.Set(err)
+	if err != nil {
+		mpu.mu.Lock()
+		defer mpu.mu.Unlock()
+		mpu.errorReport.failure(ctx, now, err)
+		return
+	}
+	mpu.processSample(ctx, rawData, now)
+}
+
+func (mpu *mpu6050) setBusUtilization(fraction float64) {
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	mpu.busUtilization = fraction
+}
+
+// processSample converts the 14 bytes of sensor data read off the chip, runs them through any
+// enabled estimators, and stores the results. It is only called from the bus scheduler's
+// goroutine.
+func (mpu *mpu6050) processSample(ctx context.Context, rawData []byte, now time.Time) {
+	linearAcceleration := toLinearAcceleration(rawData[0:6], mpu.maxAcceleration).Sub(mpu.accelBias)
+	temperature := mpu.model.temperature(rawData[6:8])
+	angularVelocity := toAngularVelocity(rawData[8:14], mpu.maxRotation)
+	angularVelocity.X -= mpu.gyroBias.X
+	angularVelocity.Y -= mpu.gyroBias.Y
+	angularVelocity.Z -= mpu.gyroBias.Z
+	if mpu.tempComp != nil {
+		bias := mpu.tempComp.gyroBias(temperature)
+		angularVelocity.X -= bias[0]
+		angularVelocity.Y -= bias[1]
+		angularVelocity.Z -= bias[2]
+	}
+	if mpu.mounting != nil {
+		// The calibration offsets are measured in the chip's frame, so they are removed first.
+		linearAcceleration = rotateVector(*mpu.mounting, linearAcceleration)
+		rotated := rotateVector(*mpu.mounting, r3.Vector{X: angularVelocity.X, Y: angularVelocity.Y, Z: angularVelocity.Z})
+		angularVelocity = spatialmath.AngularVelocity{X: rotated.X, Y: rotated.Y, Z: rotated.Z}
+	}
+	// The online bias is estimated after mounting, so it is removed afterwards too.
+	angularVelocity.X -= mpu.onlineGyroBias.X
+	angularVelocity.Y -= mpu.onlineGyroBias.Y
+	angularVelocity.Z -= mpu.onlineGyroBias.Z
+
+	var dt float64
+	if !mpu.lastSampleTime.IsZero() {
+		dt = now.Sub(mpu.lastSampleTime).Seconds()
+	}
+	mpu.lastSampleTime = now
+
+	gyroDegrees := r3.Vector{X: angularVelocity.X, Y: angularVelocity.Y, Z: angularVelocity.Z}
+	still := mpu.stillness.update(now, linearAcceleration, gyroDegrees)
+	mpu.noise.update(still, linearAcceleration, gyroDegrees)
+	gyro := r3.Vector{
+		X: utils.DegToRad(angularVelocity.X),
+		Y: utils.DegToRad(angularVelocity.Y),
+		Z: utils.DegToRad(angularVelocity.Z),
+	}
+	if mpu.tilt != nil {
+		mpu.tilt.update(linearAcceleration, dt)
+	}
+
+	if mpu.filter != nil {
+		mpu.filter.update(linearAcceleration, gyro, dt)
+		if mpu.noise.ready {
+			// Yaw is about the world's Z axis, which could be any of the sensor's axes, so use the
+			// average gyroscope noise.
+			gyroNoise := mpu.noise.gyroStdDev()
+			meanVariance := (gyroNoise.X*gyroNoise.X + gyroNoise.Y*gyroNoise.Y + gyroNoise.Z*gyroNoise.Z) / 3
+			mpu.filter.addGyroNoise(utils.DegToRad(1)*utils.DegToRad(1)*meanVariance, dt)
+		}
+		if mpu.velocity != nil {
+			mpu.velocity.update(mpu.filter.q, linearAcceleration, still, dt)
 		}
-	}()
+	}
+
+	// Lock the mutex before modifying the state within the object. By keeping the mutex unlocked
+	// for everything else, we maximize the time when another thread can read the values.
+	mpu.mu.Lock()
+	mpu.linearAcceleration = linearAcceleration
+	mpu.temperature = temperature
+	mpu.angularVelocity = angularVelocity
+	mpu.sampledAt = now
+	mpu.errorReport.success(ctx, now)
+	if mpu.flatReadings != nil || mpu.batch != nil {
+		mpu.rawCounts = parseRawSample(rawData)
+	}
+	mpu.still = still
+	mpu.motionState, mpu.timeInState = mpu.stillness.state(now)
+	mpu.noiseReady = mpu.noise.ready
+	if mpu.tilt != nil {
+		mpu.tiltAngles = mpu.tilt.angles()
+	}
+	mpu.accelNoise = mpu.noise.accelStdDev()
+	mpu.gyroNoise = mpu.noise.gyroStdDev()
+	if mpu.filter != nil {
+		mpu.orientation = mpu.filter.q
+		mpu.gravity = mpu.filter.gravity()
+		mpu.tiltUncertainty = math.Sqrt(mpu.filter.tiltVariance)
+		mpu.yawUncertainty = math.Sqrt(mpu.filter.yawVariance)
+	}
+	if mpu.velocity != nil {
+		mpu.linearVelocity = mpu.velocity.sensorFrameVelocity(mpu.filter.q)
+		mpu.velocityStdDev = mpu.velocity.stdDev()
+	}
+	if mpu.batch != nil {
+		// Gravity has to be updated first, since it may be removed from the acceleration.
+		mpu.batch.add(batchedSample{
+			time:        now,
+			accel:       mpu.reportedLinearAcceleration(),
+			gyro:        angularVelocity,
+			temperature: temperature,
+			raw:         mpu.rawCounts,
+		})
+	}
+	if mpu.pedometer != nil {
+		mpu.pedometer.update(now, linearAcceleration, dt)
+	}
+	if mpu.tap != nil {
+		mpu.tap.update(now, linearAcceleration, dt)
+	}
+	var shock *shockEvent
+	if mpu.shock != nil {
+		shock = mpu.shock.update(now, linearAcceleration)
+	}
+	if mpu.gyroBiasEstimator != nil {
+		mpu.updateGyroBias(now, gyroDegrees, still, dt)
+	}
+	mpu.mu.Unlock()
 
-	results, err := handle.ReadBlockData(ctx, register, length)
-	return results, err
+	// Logging can block, so it waits until the mutex is released.
+	if shock != nil {
+		mpu.logger.CInfof(ctx, "shock of %.2fg (x %.2fg, y %.2fg, z %.2fg) lasting %s", shock.peakMagnitude,
+			shock.peak.X, shock.peak.Y, shock.peak.Z, shock.end.Sub(shock.start))
+	}
 }
 
-func (mpu *mpu6050) writeByte(ctx context.Context, register, value byte) error {
-	handle, err := mpu.bus.OpenHandle(mpu.i2cAddress)
+// updateGyroBias feeds the online bias estimator, and must be called with the mutex held.
+func (mpu *mpu6050) updateGyroBias(now time.Time, gyro r3.Vector, still bool, dt float64) {
+	switch mpu.gyroBiasEstimator.conf.source() {
+	case gyroBiasSourceStillness:
+		if still {
+			mpu.gyroBiasEstimator.update(now, gyro, dt)
+		}
+	case gyroBiasSourceFilter:
+		if mpu.filter.initialized {
+			// The filter's integral term converges to the negative of the bias it still sees. Hand
+			// over whatever the estimator took, so the bias isn't removed twice.
+			residual := mpu.filter.integral.Mul(-utils.RadToDeg(1))
+			change := mpu.gyroBiasEstimator.update(now, residual, dt)
+			mpu.filter.integral = mpu.filter.integral.Add(change.Mul(utils.DegToRad(1)))
+		}
+	}
+	mpu.onlineGyroBias = mpu.gyroBiasEstimator.bias
+}
+
+func (mpu *mpu6050) readByte(ctx context.Context, register byte) (byte, error) {
+	result, err := mpu.readBlock(ctx, register, 1)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer func() {
-		err := handle.Close()
-		if err != nil {
-			mpu.logger.CError(ctx, err)
-		}
-	}()
+	return result[0], err
+}
+
+func (mpu *mpu6050) readBlock(ctx context.Context, register byte, length uint8) ([]byte, error) {
+	return mpu.transport.readBlock(ctx, register, length)
+}
 
-	return handle.WriteByteData(ctx, register, value)
+func (mpu *mpu6050) writeByte(ctx context.Context, register, value byte) error {
+	return mpu.transport.writeByte(ctx, register, value)
 }
 
 // Given a value, scales it so that the range of int16s becomes the range of +/- maxValue.
@@ -240,13 +594,14 @@ func setScale(value int, maxValue float64) float64 {
 }
 
 // A helper function to abstract out shared code: takes 6 bytes and gives back AngularVelocity, in
-// radians per second.
-func toAngularVelocity(data []byte) spatialmath.AngularVelocity {
+// degrees per second. maxRotation is the full-scale range of the gyroscope, in degrees per second.
+// Everything inside the driver works in degrees per second, and only converts to radians where it
+// needs to.
+func toAngularVelocity(data []byte, maxRotation float64) spatialmath.AngularVelocity {
 	gx := int(utils.Int16FromBytesBE(data[0:2]))
 	gy := int(utils.Int16FromBytesBE(data[2:4]))
 	gz := int(utils.Int16FromBytesBE(data[4:6]))
 
-	maxRotation := 250.0 // Maximum degrees per second measurable in the default configuration
 	return spatialmath.AngularVelocity{
 		X: setScale(gx, maxRotation),
 		Y: setScale(gy, maxRotation),
@@ -254,14 +609,13 @@ func toAngularVelocity(data []byte) spatialmath.AngularVelocity {
 	}
 }
 
-// A helper function that takes 6 bytes and gives back linear acceleration.
-func toLinearAcceleration(data []byte) r3.Vector {
+// A helper function that takes 6 bytes and gives back linear acceleration. maxAcceleration is the
+// full-scale range of the accelerometer, already converted from G's to m/sec/sec.
+func toLinearAcceleration(data []byte, maxAcceleration float64) r3.Vector {
 	x := int(utils.Int16FromBytesBE(data[0:2]))
 	y := int(utils.Int16FromBytesBE(data[2:4]))
 	z := int(utils.Int16FromBytesBE(data[4:6]))
 
-	// The scale is +/- 2G's, but our units should be m/sec/sec.
-	maxAcceleration := 2.0 * 9.81 /* m/sec/sec */
 	return r3.Vector{
 		X: setScale(x, maxAcceleration),
 		Y: setScale(y, maxAcceleration),
@@ -272,11 +626,17 @@ func toLinearAcceleration(data []byte) r3.Vector {
 func (mpu *mpu6050) AngularVelocity(ctx context.Context, extra map[string]interface{}) (spatialmath.AngularVelocity, error) {
 	mpu.mu.Lock()
 	defer mpu.mu.Unlock()
-	return mpu.angularVelocity, mpu.err.Get()
+	return scaleAngularVelocity(mpu.angularVelocity, mpu.angularVelocityUnit), mpu.err.Get()
 }
 
+// LinearVelocity returns the estimated velocity in the sensor frame, if the estimator is enabled.
 func (mpu *mpu6050) LinearVelocity(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
-	return r3.Vector{}, movementsensor.ErrMethodUnimplementedLinearVelocity
+	if mpu.velocity == nil {
+		return r3.Vector{}, movementsensor.ErrMethodUnimplementedLinearVelocity
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	return mpu.linearVelocity, mpu.err.Get()
 }
 
 func (mpu *mpu6050) LinearAcceleration(ctx context.Context, exta map[string]interface{}) (r3.Vector, error) {
@@ -287,11 +647,28 @@ func (mpu *mpu6050) LinearAcceleration(ctx context.Context, exta map[string]inte
 	if lastError != nil {
 		return r3.Vector{}, lastError
 	}
-	return mpu.linearAcceleration, nil
+	return mpu.reportedLinearAcceleration(), nil
 }
 
+// reportedLinearAcceleration returns the linear acceleration, with gravity removed if the config
+// asks for that. The caller must hold the mutex.
+func (mpu *mpu6050) reportedLinearAcceleration() r3.Vector {
+	if mpu.removeGravity {
+		return mpu.linearAcceleration.Sub(mpu.gravity)
+	}
+	return mpu.linearAcceleration
+}
+
+// Orientation returns the estimate from the orientation filter, which only runs when a feature
+// that needs it is enabled.
 func (mpu *mpu6050) Orientation(ctx context.Context, extra map[string]interface{}) (spatialmath.Orientation, error) {
-	return spatialmath.NewOrientationVector(), movementsensor.ErrMethodUnimplementedOrientation
+	if mpu.filter == nil {
+		return spatialmath.NewOrientationVector(), movementsensor.ErrMethodUnimplementedOrientation
+	}
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+	orientation := spatialmath.Quaternion(mpu.orientation)
+	return &orientation, mpu.err.Get()
 }
 
 func (mpu *mpu6050) CompassHeading(ctx context.Context, extra map[string]interface{}) (float64, error) {
@@ -302,8 +679,32 @@ func (mpu *mpu6050) Position(ctx context.Context, extra map[string]interface{})
 	return geo.NewPoint(0, 0), 0, movementsensor.ErrMethodUnimplementedPosition
 }
 
+// Accuracy reports standard deviations for whatever we have enough data to estimate. The sensor
+// noise is measured while the sensor is still, so it is missing until the sensor has been still for
+// a second. The MPU-6050 has no magnetometer and so no compass heading, which leaves
+// CompassDegreeError unset.
 func (mpu *mpu6050) Accuracy(ctx context.Context, extra map[string]interface{}) (*movementsensor.Accuracy, error) {
-	return movementsensor.UnimplementedOptionalAccuracies(), nil
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+
+	accuracy := movementsensor.UnimplementedOptionalAccuracies()
+	accuracy.AccuracyMap = map[string]float32{}
+	if mpu.noiseReady {
+		accuracy.AccuracyMap["linear_acceleration_x"] = float32(mpu.accelNoise.X)
+		accuracy.AccuracyMap["linear_acceleration_y"] = float32(mpu.accelNoise.Y)
+		accuracy.AccuracyMap["linear_acceleration_z"] = float32(mpu.accelNoise.Z)
+		accuracy.AccuracyMap["angular_velocity_x"] = float32(mpu.gyroNoise.X)
+		accuracy.AccuracyMap["angular_velocity_y"] = float32(mpu.gyroNoise.Y)
+		accuracy.AccuracyMap["angular_velocity_z"] = float32(mpu.gyroNoise.Z)
+	}
+	if mpu.filter != nil {
+		accuracy.AccuracyMap["orientation_tilt"] = float32(utils.RadToDeg(mpu.tiltUncertainty))
+		accuracy.AccuracyMap["orientation_yaw"] = float32(utils.RadToDeg(mpu.yawUncertainty))
+	}
+	if mpu.velocity != nil {
+		accuracy.AccuracyMap["linear_velocity"] = float32(mpu.velocityStdDev)
+	}
+	return accuracy, nil
 }
 
 func (mpu *mpu6050) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
@@ -311,9 +712,79 @@ func (mpu *mpu6050) Readings(ctx context.Context, extra map[string]interface{})
 	defer mpu.mu.Unlock()
 
 	readings := make(map[string]interface{})
-	readings["linear_acceleration"] = mpu.linearAcceleration
+	readings["linear_acceleration"] = mpu.reportedLinearAcceleration()
 	readings["temperature_celsius"] = mpu.temperature
-	readings["angular_velocity"] = mpu.angularVelocity
+	readings["angular_velocity"] = scaleAngularVelocity(mpu.angularVelocity, mpu.angularVelocityUnit)
+	readings["motion_state"] = mpu.motionState
+	readings["time_in_state_sec"] = mpu.timeInState.Seconds()
+	readings["bus_utilization"] = mpu.busUtilization
+	for key, value := range mpu.errorReport.readings() {
+		readings[key] = value
+	}
+	if mpu.velocity != nil {
+		readings["linear_velocity"] = mpu.linearVelocity
+	}
+	if mpu.filter != nil {
+		orientation := spatialmath.Quaternion(mpu.orientation)
+		readings["orientation"] = &orientation
+	}
+	userAcceleration := mpu.linearAcceleration.Sub(mpu.gravity)
+	if mpu.reportUserAcceleration {
+		readings["user_acceleration"] = userAcceleration
+		readings["gravity"] = mpu.gravity
+	}
+	if mpu.reportWorldFrame {
+		readings["user_acceleration_world"] = rotateToWorld(mpu.orientation, userAcceleration)
+		readings["gravity_world"] = rotateToWorld(mpu.orientation, mpu.gravity)
+	}
+	if mpu.tilt != nil {
+		readings["pitch_deg"] = mpu.tiltAngles.pitch
+		readings["roll_deg"] = mpu.tiltAngles.roll
+		readings["inclination_deg"] = mpu.tiltAngles.inclination
+		readings["tilt_stable"] = mpu.still
+	}
+	if mpu.pedometer != nil {
+		readings["steps"] = mpu.pedometer.steps
+		readings["cadence_spm"] = mpu.pedometer.cadence
+		readings["activity"] = mpu.pedometer.activity()
+	}
+	if mpu.tap != nil {
+		readings["taps"] = mpu.tap.taps
+		readings["double_taps"] = mpu.tap.doubleTaps
+	}
+	if mpu.shock != nil {
+		for key, value := range mpu.shock.readings() {
+			readings[key] = value
+		}
+	}
+	if mpu.vibrationResult != nil {
+		for key, value := range mpu.vibrationResult.readings(mpu.vibration.conf.Bands) {
+			readings[key] = value
+		}
+	}
+	if mpu.gyroBiasEstimator != nil {
+		for key, value := range mpu.gyroBiasEstimator.readings(time.Now()) {
+			readings[key] = value
+		}
+	}
+	if mpu.flatReadings != nil {
+		flat := mpu.flatReadings.readings(mpu.reportedLinearAcceleration(), mpu.angularVelocity, mpu.rawCounts,
+			mpu.maxAcceleration, mpu.maxRotation)
+		for key, value := range flat {
+			readings[key] = value
+		}
+	}
+	if mpu.batch != nil {
+		// The samples use the flat readings' units. If they aren't configured, the angular velocity
+		// is in the same unit as the angular_velocity reading next to them.
+		flat := mpu.flatReadings
+		if flat == nil {
+			flat = &FlatReadings{GyroUnit: mpu.angularVelocityUnit}
+		}
+		for key, value := range mpu.batch.take(flat, mpu.maxAcceleration, mpu.maxRotation) {
+			readings[key] = value
+		}
+	}
 
 	return readings, mpu.err.Get()
 }
@@ -322,11 +793,17 @@ func (mpu *mpu6050) Properties(ctx context.Context, extra map[string]interface{}
 	return &movementsensor.Properties{
 		AngularVelocitySupported:    true,
 		LinearAccelerationSupported: true,
+		LinearVelocitySupported:     mpu.velocity != nil,
+		OrientationSupported:        mpu.filter != nil,
 	}, nil
 }
 
 func (mpu *mpu6050) Close(ctx context.Context) error {
-	mpu.workers.Stop()
+	unscheduleFromBus(mpu.transport.busKey(), mpu)
+	if detachMovementSensor(mpu.chipKey, mpu) {
+		// A temperature sensor is still reading the chip, so leave it awake.
+		return nil
+	}
 
 	mpu.mu.Lock()
 	defer mpu.mu.Unlock()
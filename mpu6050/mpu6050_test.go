@@ -1,12 +1,14 @@
-//go:build linux
-
 package mpu6050
 
 import (
 	"context"
+	"math"
+	"sync"
 	"testing"
 
+	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board"
 	"go.viam.com/rdk/components/board/genericlinux/buses"
 	"go.viam.com/rdk/components/movementsensor"
 	"go.viam.com/rdk/logging"
@@ -17,7 +19,11 @@ import (
 )
 
 const i2cName = "i2c"
-var testName = movementsensor.Named("foo")
+
+var (
+	testName  = movementsensor.Named("foo")
+	altConfig = &Config{I2cBus: i2cName, UseAlternateI2CAddress: true}
+)
 
 func TestValidateConfig(t *testing.T) {
 	cfg := Config{}
@@ -25,6 +31,57 @@ func TestValidateConfig(t *testing.T) {
 	expectedErr := resource.NewConfigValidationFieldRequiredError("path", "i2c_bus")
 	test.That(t, err, test.ShouldBeError, expectedErr)
 	test.That(t, deps, test.ShouldBeEmpty)
+
+	cfg = Config{I2cBus: i2cName, GyroRange: 300}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "gyro_range_dps")
+
+	cfg = Config{I2cBus: i2cName, AccelRange: 3}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "accel_range_g")
+
+	cfg = Config{I2cBus: i2cName, Calibration: &Calibration{GyroBias: []float64{1, 2}}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "gyro_bias_dps")
+
+	cfg = Config{I2cBus: i2cName, GyroRange: 2000, AccelRange: 16}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+
+	cfg = Config{SPI: &SPI{Bus: "0", ChipSelect: "0"}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+
+	cfg = Config{I2cBus: i2cName, I2cAddress: 0x6A, Mux: &Mux{Address: 0x74, Channel: 7}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cfg.address(), test.ShouldEqual, 0x6A)
+
+	cfg = Config{I2cBus: i2cName, I2cAddress: 0x6A, UseAlternateI2CAddress: true}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cfg = Config{I2cBus: i2cName, I2cAddress: 0x80}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "i2c_address")
+
+	cfg = Config{I2cBus: i2cName, Mux: &Mux{Channel: 8}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "mux.channel")
+
+	cfg = Config{I2cBus: i2cName, SPI: &SPI{Bus: "0", ChipSelect: "0"}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cfg = Config{SPI: &SPI{Bus: "0", ChipSelect: "0", SensorSpeedHz: 40000000}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "sensor_speed_hz")
 }
 
 func TestInitializationFailureOnChipCommunication(t *testing.T) {
@@ -45,9 +102,9 @@ func TestInitializationFailureOnChipCommunication(t *testing.T) {
 			return i2cHandle, nil
 		}
 
-		sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, false)
+		sensor, err := makeMpu6050(context.Background(), logger, testName, &Config{I2cBus: i2cName}, i2c)
 		test.That(t, err, test.ShouldNotBeNil)
-		test.That(t, err, test.ShouldBeError, addressReadError(readErr, expectedDefaultAddress, i2cName))
+		test.That(t, err, test.ShouldBeError, readError(readErr, newI2CTransport(i2c, "", i2cName, expectedDefaultAddress, logger)))
 		test.That(t, sensor, test.ShouldBeNil)
 	})
 
@@ -65,9 +122,9 @@ func TestInitializationFailureOnChipCommunication(t *testing.T) {
 			return i2cHandle, nil
 		}
 
-		sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, true)
+		sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
 		test.That(t, err, test.ShouldNotBeNil)
-		test.That(t, err, test.ShouldBeError, unexpectedDeviceError(alternateAddress, 0x64))
+		test.That(t, err, test.ShouldBeError, unexpectedDeviceError(newI2CTransport(i2c, "", i2cName, alternateAddress, logger), 0x64))
 		test.That(t, sensor, test.ShouldBeNil)
 	})
 }
@@ -96,7 +153,7 @@ func TestSuccessfulInitializationAndClose(t *testing.T) {
 		return i2cHandle, nil
 	}
 
-	sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, true)
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
 	test.That(t, err, test.ShouldBeNil)
 	err = sensor.Close(context.Background())
 	test.That(t, err, test.ShouldBeNil)
@@ -144,7 +201,7 @@ func TestLinearAcceleration(t *testing.T) {
 	logger := logging.NewTestLogger(t)
 
 	i2c := setupDependencies(linearAccelMockData)
-	sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, true)
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
 	test.That(t, err, test.ShouldBeNil)
 	defer sensor.Close(context.Background())
 	testutils.WaitForAssertion(t, func(tb testing.TB) {
@@ -180,7 +237,7 @@ func TestAngularVelocity(t *testing.T) {
 
 	logger := logging.NewTestLogger(t)
 	i2c := setupDependencies(angVelMockData)
-	sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, true)
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
 	test.That(t, err, test.ShouldBeNil)
 	defer sensor.Close(context.Background())
 	testutils.WaitForAssertion(t, func(tb testing.TB) {
@@ -206,7 +263,7 @@ func TestTemperature(t *testing.T) {
 
 	logger := logging.NewTestLogger(t)
 	i2c := setupDependencies(temperatureMockData)
-	sensor, err := makeMpu6050(context.Background(), logger, testName, i2cName, i2c, true)
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
 	test.That(t, err, test.ShouldBeNil)
 	defer sensor.Close(context.Background())
 	testutils.WaitForAssertion(t, func(tb testing.TB) {
@@ -218,3 +275,587 @@ func TestTemperature(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, readings["temperature_celsius"], test.ShouldAlmostEqual, expectedTemp, 0.001)
 }
+
+func TestRangesAndCalibration(t *testing.T) {
+	mockData := make([]byte, 16)
+	// x-accel is half of full scale, which is 2g at +/- 4g.
+	mockData[0] = 64
+	// x-vel is half of full scale, which is 1000 deg/sec at +/- 2000 deg/sec.
+	mockData[8] = 64
+
+	logger := logging.NewTestLogger(t)
+	i2c := setupDependencies(mockData)
+	cfg := &Config{
+		I2cBus:      i2cName,
+		GyroRange:   2000,
+		AccelRange:  4,
+		Calibration: &Calibration{GyroBias: []float64{10, 0, 0}, AccelBias: []float64{0.5, 0, 0}},
+	}
+	sensor, err := makeMpu6050(context.Background(), logger, testName, cfg, i2c)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		angVel, err := sensor.AngularVelocity(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, angVel, test.ShouldNotBeZeroValue)
+	})
+	angVel, err := sensor.AngularVelocity(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, angVel.X, test.ShouldAlmostEqual, 990.0)
+	accel, err := sensor.LinearAcceleration(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, accel.X, test.ShouldAlmostEqual, 2*9.81-0.5)
+}
+
+func TestRegisterCommands(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	written := map[byte]byte{}
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		switch register {
+		case defaultAddressRegister:
+			return []byte{expectedDefaultAddress}, nil
+		case powerRegister:
+			return []byte{written[powerRegister], 0}[:numBytes], nil
+		case configRegister:
+			return []byte{written[configRegister]}, nil
+		}
+		return make([]byte, numBytes), nil
+	}
+	i2cHandle.WriteByteDataFunc = func(ctx context.Context, register, data byte) error {
+		written[register] = data
+		return nil
+	}
+	i2cHandle.CloseFunc = func() error { return nil }
+	i2c := &inject.I2C{}
+	i2c.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, i2c)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+	ctx := context.Background()
+
+	t.Run("read_register decodes bit fields", func(t *testing.T) {
+		written[powerRegister] = 1<<6 | 1
+		resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "read_register", "register": "PWR_MGMT_1"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["register"], test.ShouldEqual, powerRegister)
+		test.That(t, resp["value"], test.ShouldEqual, 0x41)
+		fields := resp["fields"].(map[string]interface{})
+		test.That(t, fields["PWR_MGMT_1.SLEEP"], test.ShouldEqual, 1)
+		test.That(t, fields["PWR_MGMT_1.CLKSEL"], test.ShouldEqual, 1)
+		written[powerRegister] = 0
+	})
+
+	t.Run("read_registers decodes a range", func(t *testing.T) {
+		resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "read_registers", "start": 107.0, "count": 2.0})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["values"], test.ShouldResemble, []interface{}{0, 0})
+		test.That(t, resp["registers"], test.ShouldContainKey, "PWR_MGMT_2")
+		test.That(t, resp["fields"], test.ShouldContainKey, "PWR_MGMT_2.STBY_XA")
+
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "read_registers", "start": 117.0, "count": 2.0})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("reads that pop the FIFO need force", func(t *testing.T) {
+		_, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "read_registers", "start": 114.0, "count": 4.0})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "FIFO_R_W")
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "read_register", "register": "FIFO_R_W"})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{
+			"command": "read_registers", "start": 114.0, "count": 4.0, "force": true,
+		})
+		test.That(t, err, test.ShouldBeNil)
+	})
+
+	t.Run("write_register guards registers", func(t *testing.T) {
+		resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "write_register", "register": "0x1A", "value": 3.0})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["fields"].(map[string]interface{})["CONFIG.DLPF_CFG"], test.ShouldEqual, 3)
+
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "write_register", "register": "WHO_AM_I", "value": 0.0})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "read-only")
+
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "write_register", "register": "GYRO_CONFIG", "value": 8.0})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, written[gyroConfigRegister], test.ShouldEqual, 0)
+
+		_, err = sensor.DoCommand(ctx, map[string]interface{}{
+			"command": "write_register", "register": "GYRO_CONFIG", "value": 8.0, "force": true,
+		})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, written[gyroConfigRegister], test.ShouldEqual, 8)
+	})
+
+	t.Run("USER_CTRL is managed over SPI", func(t *testing.T) {
+		test.That(t, sensor.(*mpu6050).driverManaged(userControlRegister), test.ShouldBeFalse)
+		spi := &mpu6050{transport: newSPITransport(nil, SPI{Bus: "0", ChipSelect: "0"}, logger)}
+		test.That(t, spi.driverManaged(userControlRegister), test.ShouldBeTrue)
+	})
+}
+
+func TestLinearVelocityEstimate(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// Without the estimator, linear velocity and orientation are unimplemented.
+	sensor, err := makeMpu6050(context.Background(), logger, testName, altConfig, setupDependencies(make([]byte, 16)))
+	test.That(t, err, test.ShouldBeNil)
+	_, err = sensor.LinearVelocity(context.Background(), nil)
+	test.That(t, err, test.ShouldBeError, movementsensor.ErrMethodUnimplementedLinearVelocity)
+	test.That(t, sensor.Close(context.Background()), test.ShouldBeNil)
+
+	// A level sensor at rest: 1g on the z axis, which is a quarter of the +/- 2g full scale.
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, EstimateLinearVelocity: true}
+	sensor, err = makeMpu6050(context.Background(), logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+
+	props, err := sensor.Properties(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.LinearVelocitySupported, test.ShouldBeTrue)
+	test.That(t, props.OrientationSupported, test.ShouldBeTrue)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accuracy, err := sensor.Accuracy(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		// Once the zero-velocity update fires, the uncertainty drops back to zero.
+		test.That(tb, accuracy.AccuracyMap["linear_velocity"], test.ShouldEqual, 0)
+		velocity, err := sensor.LinearVelocity(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, velocity.Norm(), test.ShouldBeLessThan, 1e-3)
+	})
+}
+
+func TestAccuracy(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// A level sensor at rest. The mock returns identical samples, so the measured noise is zero, but
+	// it is only reported once a full window of still samples has been seen.
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, ReportUserAcceleration: true}
+	sensor, err := makeMpu6050(context.Background(), logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accuracy, err := sensor.Accuracy(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		for _, key := range []string{
+			"linear_acceleration_x", "linear_acceleration_y", "linear_acceleration_z",
+			"angular_velocity_x", "angular_velocity_y", "angular_velocity_z",
+			"orientation_tilt", "orientation_yaw",
+		} {
+			test.That(tb, accuracy.AccuracyMap, test.ShouldContainKey, key)
+			test.That(tb, accuracy.AccuracyMap[key], test.ShouldEqual, 0)
+		}
+		test.That(tb, accuracy.AccuracyMap, test.ShouldNotContainKey, "linear_velocity")
+		// There is no magnetometer, so no compass error.
+		test.That(tb, math.IsNaN(float64(accuracy.CompassDegreeError)), test.ShouldBeTrue)
+	})
+}
+
+func TestGravityCompensation(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// A sensor lying on its side at rest: 1g on the y axis.
+	mockData := make([]byte, 16)
+	mockData[2] = 64
+	cfg := &Config{I2cBus: i2cName, RemoveGravity: true, ReportUserAcceleration: true, ReportWorldFrame: true}
+	sensor, err := makeMpu6050(context.Background(), logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		gravityReading := readings["gravity"].(r3.Vector)
+		test.That(tb, gravityReading.Y, test.ShouldAlmostEqual, 9.81, 1e-6)
+		test.That(tb, readings["user_acceleration"].(r3.Vector).Norm(), test.ShouldBeLessThan, 1e-6)
+		test.That(tb, readings["user_acceleration_world"].(r3.Vector).Norm(), test.ShouldBeLessThan, 1e-6)
+		test.That(tb, readings["gravity_world"].(r3.Vector).Z, test.ShouldAlmostEqual, 9.81, 1e-6)
+	})
+	accel, err := sensor.LinearAcceleration(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, accel.Norm(), test.ShouldBeLessThan, 1e-6)
+}
+
+func TestMounting(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// 1g on the chip's z axis and some rotation about its x axis, with the chip mounted upside-down.
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	mockData[8] = 64
+	cfg := &Config{I2cBus: i2cName, Mounting: &Mounting{Axes: []string{"-x", "y", "-z"}}}
+	sensor, err := makeMpu6050(context.Background(), logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accel, err := sensor.LinearAcceleration(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, accel.Z, test.ShouldAlmostEqual, -9.81)
+	})
+	angVel, err := sensor.AngularVelocity(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, angVel.X, test.ShouldAlmostEqual, -125.0)
+}
+
+func TestVibration(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// The FIFO always holds 20 samples of a 125Hz tone on X with an amplitude of half a g, which is
+	// 8192 counts at the +/- 2g full scale.
+	var mu sync.Mutex
+	sampleIndex := 0
+	var writes []byte
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		switch register {
+		case defaultAddressRegister:
+			return []byte{expectedDefaultAddress}, nil
+		case intStatusRegister:
+			return []byte{0}, nil
+		case fifoCountRegister:
+			return []byte{0, 120}, nil
+		case fifoDataRegister:
+			mu.Lock()
+			defer mu.Unlock()
+			data := make([]byte, numBytes)
+			for i := 0; i+6 <= len(data); i += 6 {
+				x := int16(8192 * math.Sin(2*math.Pi*125*float64(sampleIndex)/vibrationSampleRate))
+				data[i], data[i+1] = byte(uint16(x)>>8), byte(x)
+				sampleIndex++
+			}
+			return data, nil
+		default:
+			return make([]byte, numBytes), nil
+		}
+	}
+	i2cHandle.WriteByteDataFunc = func(ctx context.Context, register, value byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		writes = append(writes, register, value)
+		return nil
+	}
+	i2cHandle.CloseFunc = func() error { return nil }
+	i2c := &inject.I2C{}
+	i2c.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	cfg := &Config{I2cBus: i2cName, Vibration: &Vibration{
+		WindowSamples: 64,
+		Bands:         []VibrationBand{{Name: "tone", MinHz: 100, MaxHz: 150}},
+	}}
+	sensor, err := makeMpu6050(ctx, logger, testName, cfg, i2c)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	mu.Lock()
+	// The FIFO is set up for accelerometer samples at 1kHz.
+	test.That(t, writes, test.ShouldContain, byte(fifoEnableRegister))
+	test.That(t, writes, test.ShouldContain, byte(sampleRateDivRegister))
+	mu.Unlock()
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings, test.ShouldContainKey, "vibration_peak_1_hz")
+		test.That(tb, readings["vibration_peak_1_hz"], test.ShouldAlmostEqual, 125, 1e-6)
+		test.That(tb, readings["vibration_peak_1_mps2"], test.ShouldAlmostEqual, 4.905, 0.01)
+		test.That(tb, readings["vibration_band_tone_rms_mps2"], test.ShouldAlmostEqual, 4.905/math.Sqrt2, 0.01)
+	})
+
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "vibration", "include_spectrum": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["window_samples"], test.ShouldEqual, 64)
+	test.That(t, resp["fifo_overflows"], test.ShouldEqual, 0)
+	spectrum := resp["spectrum"].(map[string]interface{})
+	test.That(t, len(spectrum["frequencies_hz"].([]interface{})), test.ShouldEqual, 33)
+
+	// The FIFO registers are now the driver's.
+	_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "write_register", "register": "FIFO_EN", "value": 0.0})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestShockCommands(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	sensor, err := makeMpu6050(ctx, logger, testName, altConfig, setupDependencies(make([]byte, 16)))
+	test.That(t, err, test.ShouldBeNil)
+	_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "shock_events"})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, sensor.Close(ctx), test.ShouldBeNil)
+
+	// A level sensor at rest never reaches the threshold.
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, Shock: &Shock{ThresholdG: 2, WindowSec: 0.01}}
+	sensor, err = makeMpu6050(ctx, logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["shock_events"], test.ShouldEqual, 0)
+		test.That(tb, readings, test.ShouldContainKey, "peak_magnitude_g")
+		test.That(tb, readings["peak_magnitude_g"], test.ShouldAlmostEqual, 1)
+		test.That(tb, readings["rms_g"], test.ShouldAlmostEqual, 0)
+	})
+
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "shock_events"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["events"], test.ShouldBeEmpty)
+	test.That(t, resp["since_reset"].(map[string]interface{})["peak_magnitude_g"], test.ShouldAlmostEqual, 1)
+	test.That(t, resp, test.ShouldContainKey, "last_window")
+
+	_, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "reset_shock"})
+	test.That(t, err, test.ShouldBeNil)
+}
+
+func TestTilt(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	// A sensor lying on its side at rest: 1g on the y axis.
+	mockData := make([]byte, 16)
+	mockData[2] = 64
+	cfg := &Config{I2cBus: i2cName, Tilt: &Tilt{}}
+	sensor, err := makeMpu6050(context.Background(), logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(context.Background())
+
+	// Tilt doesn't need the orientation filter.
+	props, err := sensor.Properties(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.OrientationSupported, test.ShouldBeFalse)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(context.Background(), nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["roll_deg"], test.ShouldAlmostEqual, 90)
+		test.That(tb, readings["pitch_deg"], test.ShouldAlmostEqual, 0)
+		test.That(tb, readings["inclination_deg"], test.ShouldAlmostEqual, 90)
+		test.That(tb, readings["tilt_stable"], test.ShouldBeTrue)
+	})
+}
+
+func TestMotionState(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, Stillness: &Stillness{WindowSamples: 20}}
+	sensor, err := makeMpu6050(ctx, logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["motion_state"], test.ShouldEqual, "still")
+		test.That(tb, readings["time_in_state_sec"], test.ShouldBeGreaterThan, 0)
+	})
+
+	_, err = (&Config{I2cBus: i2cName, Stillness: &Stillness{WindowSamples: 1}}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestGyroBiasCommands(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// At rest, with a gyroscope bias of 1 deg/sec on the x axis.
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	mockData[9] = 131
+	cfg := &Config{
+		I2cBus:    i2cName,
+		Stillness: &Stillness{WindowSamples: 20},
+		GyroBias:  &GyroBias{TimeConstantSec: 0.01, MaxRate: 100},
+	}
+	sensor, err := makeMpu6050(ctx, logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["gyro_bias_x_dps"], test.ShouldAlmostEqual, 1, 0.01)
+		test.That(tb, readings["gyro_bias_y_dps"], test.ShouldEqual, 0)
+		test.That(tb, readings["gyro_bias_age_sec"], test.ShouldBeLessThan, 1)
+		angularVelocity, err := sensor.AngularVelocity(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, angularVelocity.X, test.ShouldAlmostEqual, 0, 0.01)
+	})
+
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "freeze_gyro_bias"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["gyro_bias_frozen"], test.ShouldBeTrue)
+
+	resp, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "reset_gyro_bias"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["gyro_bias_x_dps"], test.ShouldEqual, 0)
+	test.That(t, resp, test.ShouldNotContainKey, "gyro_bias_age_sec")
+
+	// Frozen at zero, the bias comes back through.
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		angularVelocity, err := sensor.AngularVelocity(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, angularVelocity.X, test.ShouldAlmostEqual, 1, 0.01)
+	})
+
+	resp, err = sensor.DoCommand(ctx, map[string]interface{}{"command": "unfreeze_gyro_bias"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["gyro_bias_frozen"], test.ShouldBeFalse)
+
+	plain, err := makeMpu6050(ctx, logger, testName, &Config{I2cBus: i2cName}, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer plain.Close(ctx)
+	_, err = plain.DoCommand(ctx, map[string]interface{}{"command": "reset_gyro_bias"})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestSharedBus(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	bus := setupDependencies(mockData)
+	first, err := makeMpu6050(ctx, logger, testName, &Config{I2cBus: i2cName}, bus)
+	test.That(t, err, test.ShouldBeNil)
+	defer first.Close(ctx)
+	second, err := makeMpu6050(ctx, logger, movementsensor.Named("bar"), altConfig, bus)
+	test.That(t, err, test.ShouldBeNil)
+
+	// Both sensors are sampled by the same scheduler.
+	schedulers.mu.Lock()
+	scheduler := schedulers.byBus["i2c:/"+i2cName]
+	schedulers.mu.Unlock()
+	scheduler.mu.Lock()
+	test.That(t, scheduler.clients, test.ShouldHaveLength, 2)
+	scheduler.mu.Unlock()
+
+	for _, sensor := range []movementsensor.MovementSensor{first, second} {
+		testutils.WaitForAssertion(t, func(tb testing.TB) {
+			readings, err := sensor.Readings(ctx, nil)
+			test.That(tb, err, test.ShouldBeNil)
+			test.That(tb, readings["linear_acceleration"].(r3.Vector).Z, test.ShouldAlmostEqual, 9.81)
+			test.That(tb, readings["bus_utilization"], test.ShouldBeGreaterThan, 0)
+		})
+	}
+
+	test.That(t, second.Close(ctx), test.ShouldBeNil)
+	scheduler.mu.Lock()
+	test.That(t, scheduler.clients, test.ShouldHaveLength, 1)
+	scheduler.mu.Unlock()
+}
+
+// i2cBoard is a board that supplies I2C buses.
+type i2cBoard struct {
+	*inject.Board
+	buses map[string]buses.I2C
+}
+
+func (b *i2cBoard) I2CByName(name string) (buses.I2C, error) {
+	bus, ok := b.buses[name]
+	if !ok {
+		return nil, errors.Errorf("no I2C bus named %s", name)
+	}
+	return bus, nil
+}
+
+func TestBoardBus(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	cfg := &Config{Board: "board1", I2cBus: i2cName}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"board1"})
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	b := &i2cBoard{Board: inject.NewBoard("board1"), buses: map[string]buses.I2C{i2cName: setupDependencies(mockData)}}
+	resourceConfig := resource.Config{Name: "foo", ConvertedAttributes: cfg}
+	sensor, err := newMpu6050(ctx, resource.Dependencies{board.Named("board1"): b}, resourceConfig, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accel, err := sensor.LinearAcceleration(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, accel.Z, test.ShouldAlmostEqual, 9.81)
+	})
+
+	// The bus has to exist, and the board has to be able to supply it.
+	resourceConfig.ConvertedAttributes = &Config{Board: "board1", I2cBus: "missing"}
+	_, err = newMpu6050(ctx, resource.Dependencies{board.Named("board1"): b}, resourceConfig, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no I2C bus named missing")
+	_, err = newMpu6050(ctx, resource.Dependencies{board.Named("board1"): inject.NewBoard("board1")}, resourceConfig, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "I2CByName")
+}
+
+func TestPedometerReadings(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, Pedometer: &Pedometer{}}
+	sensor, err := makeMpu6050(ctx, logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["steps"], test.ShouldEqual, 0)
+		test.That(tb, readings["cadence_spm"], test.ShouldEqual, 0)
+		test.That(tb, readings["activity"], test.ShouldEqual, "still")
+	})
+
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "reset_steps"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["steps"], test.ShouldEqual, 0)
+}
+
+func TestTapEvents(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	mockData := make([]byte, 16)
+	mockData[4] = 64
+	cfg := &Config{I2cBus: i2cName, Tap: &Tap{}}
+	sensor, err := makeMpu6050(ctx, logger, testName, cfg, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := sensor.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["taps"], test.ShouldEqual, 0)
+		test.That(tb, readings["double_taps"], test.ShouldEqual, 0)
+	})
+
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "tap_events"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["events"], test.ShouldBeEmpty)
+	test.That(t, resp["dropped"], test.ShouldEqual, 0)
+}
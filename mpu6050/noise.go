@@ -0,0 +1,82 @@
+package mpu6050
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+const (
+	// Noise is measured over windows of this many consecutive still samples; a window that is
+	// interrupted by motion is thrown away.
+	noiseWindowSamples = 1000
+	// Each completed window is blended into the reported variance with this weight, so the estimate
+	// follows slow changes without jumping around.
+	noiseSmoothing = 0.2
+)
+
+// runningVariance accumulates the mean and variance of a series using Welford's algorithm.
+type runningVariance struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+func (rv *runningVariance) add(x float64) {
+	rv.count++
+	delta := x - rv.mean
+	rv.mean += delta / float64(rv.count)
+	rv.m2 += delta * (x - rv.mean)
+}
+
+func (rv *runningVariance) variance() float64 {
+	if rv.count < 2 {
+		return 0
+	}
+	return rv.m2 / float64(rv.count-1)
+}
+
+// noiseEstimator measures the sample-to-sample noise of the accelerometer and gyroscope while the
+// sensor is still.
+type noiseEstimator struct {
+	window [6]runningVariance
+	// variance holds the smoothed per-axis variance: accelerometer X, Y, Z in (m/sec/sec)^2 and then
+	// gyroscope X, Y, Z in (degrees per second)^2. It is only meaningful once ready is true.
+	variance [6]float64
+	ready    bool
+}
+
+// update takes acceleration in m/sec/sec and angular velocity in degrees per second.
+func (n *noiseEstimator) update(still bool, accel, gyro r3.Vector) {
+	if !still {
+		n.window = [6]runningVariance{}
+		return
+	}
+
+	for i, value := range [6]float64{accel.X, accel.Y, accel.Z, gyro.X, gyro.Y, gyro.Z} {
+		n.window[i].add(value)
+	}
+	if n.window[0].count < noiseWindowSamples {
+		return
+	}
+
+	for i := range n.window {
+		if n.ready {
+			n.variance[i] += noiseSmoothing * (n.window[i].variance() - n.variance[i])
+		} else {
+			n.variance[i] = n.window[i].variance()
+		}
+	}
+	n.ready = true
+	n.window = [6]runningVariance{}
+}
+
+// accelStdDev returns the standard deviation of each accelerometer axis in m/sec/sec.
+func (n *noiseEstimator) accelStdDev() r3.Vector {
+	return r3.Vector{X: math.Sqrt(n.variance[0]), Y: math.Sqrt(n.variance[1]), Z: math.Sqrt(n.variance[2])}
+}
+
+// gyroStdDev returns the standard deviation of each gyroscope axis in degrees per second.
+func (n *noiseEstimator) gyroStdDev() r3.Vector {
+	return r3.Vector{X: math.Sqrt(n.variance[3]), Y: math.Sqrt(n.variance[4]), Z: math.Sqrt(n.variance[5])}
+}
@@ -0,0 +1,56 @@
+package mpu6050
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestNoiseEstimator(t *testing.T) {
+	var noise noiseEstimator
+	rng := rand.New(rand.NewSource(1))
+	sample := func(accelSigma, gyroSigma float64) (r3.Vector, r3.Vector) {
+		accel := r3.Vector{X: rng.NormFloat64() * accelSigma, Y: rng.NormFloat64() * accelSigma, Z: gravity + rng.NormFloat64()*accelSigma}
+		gyro := r3.Vector{X: rng.NormFloat64() * gyroSigma, Y: rng.NormFloat64() * gyroSigma, Z: rng.NormFloat64() * gyroSigma}
+		return accel, gyro
+	}
+
+	// Motion never counts toward the estimate.
+	for range 5 * noiseWindowSamples {
+		accel, gyro := sample(1, 10)
+		noise.update(false, accel, gyro)
+	}
+	test.That(t, noise.ready, test.ShouldBeFalse)
+
+	for range 20 * noiseWindowSamples {
+		accel, gyro := sample(0.02, 0.1)
+		noise.update(true, accel, gyro)
+	}
+	test.That(t, noise.ready, test.ShouldBeTrue)
+	for _, stdDev := range []float64{noise.accelStdDev().X, noise.accelStdDev().Y, noise.accelStdDev().Z} {
+		test.That(t, stdDev, test.ShouldAlmostEqual, 0.02, 0.002)
+	}
+	for _, stdDev := range []float64{noise.gyroStdDev().X, noise.gyroStdDev().Y, noise.gyroStdDev().Z} {
+		test.That(t, stdDev, test.ShouldAlmostEqual, 0.1, 0.01)
+	}
+}
+
+func TestOrientationUncertainty(t *testing.T) {
+	filter := newOrientationFilter()
+	accel := r3.Vector{Z: gravity}
+	filter.update(accel, r3.Vector{}, 0)
+
+	// A level, still sensor has nothing to correct, so tilt stays certain while yaw uncertainty
+	// grows as a random walk.
+	dt := 0.001
+	sigma := 0.01 // rad/sec
+	for range 1000 {
+		filter.update(accel, r3.Vector{}, dt)
+		filter.addGyroNoise(sigma*sigma, dt)
+	}
+	test.That(t, filter.tiltVariance, test.ShouldBeLessThan, 1e-12)
+	test.That(t, math.Sqrt(filter.yawVariance), test.ShouldAlmostEqual, sigma*dt*math.Sqrt(1000), 1e-9)
+}
@@ -0,0 +1,166 @@
+package mpu6050
+
+import (
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultStepThreshold  = 0.15 // g
+	defaultRunningCadence = 140  // steps per minute
+
+	// Nobody takes more than 4 steps a second, and a pause of 2 seconds ends a walk.
+	minStepInterval = 250 * time.Millisecond
+	maxStepInterval = 2 * time.Second
+	// A walk only starts counting once this many steps have come at a walking pace, so that a bump
+	// or a single shuffle doesn't count. The steps that confirmed the walk are counted then.
+	stepsToConfirm = 4
+	// Cadence is averaged over this many of the most recent step intervals.
+	cadenceIntervals = 4
+
+	// Time constants of the two filters on the acceleration magnitude: one smooths out impacts and
+	// vibration, and the other follows the slowly changing baseline (gravity and any sensor bias).
+	stepSmoothingSec = 0.05
+	stepBaselineSec  = 1.0
+)
+
+// Activity classifications reported by the pedometer.
+const (
+	activityStill   = "still"
+	activityWalking = "walking"
+	activityRunning = "running"
+)
+
+// Pedometer configures step counting and activity classification, for sensors worn on the body.
+type Pedometer struct {
+	// ThresholdG is how far the acceleration magnitude must rise above its baseline to count as a
+	// step.
+	ThresholdG float64 `json:"threshold_g,omitempty"`
+	// RunningCadence is the cadence, in steps per minute, at and above which the activity is
+	// running rather than walking.
+	RunningCadence float64 `json:"running_cadence_spm,omitempty"`
+}
+
+func (p *Pedometer) validate() error {
+	if p.ThresholdG < 0 {
+		return errors.Errorf("pedometer.threshold_g must not be negative, got %g", p.ThresholdG)
+	}
+	if p.RunningCadence < 0 {
+		return errors.Errorf("pedometer.running_cadence_spm must not be negative, got %g", p.RunningCadence)
+	}
+	return nil
+}
+
+func (p *Pedometer) threshold() float64 {
+	if p.ThresholdG == 0 {
+		return defaultStepThreshold
+	}
+	return p.ThresholdG
+}
+
+func (p *Pedometer) runningCadence() float64 {
+	if p.RunningCadence == 0 {
+		return defaultRunningCadence
+	}
+	return p.RunningCadence
+}
+
+// pedometer counts steps from peaks in the acceleration magnitude.
+type pedometer struct {
+	conf Pedometer
+
+	smoothed, baseline float64 // g
+	initialized        bool
+	// armed is set once the signal drops below its baseline, so that each step is only counted
+	// once.
+	armed    bool
+	lastStep time.Time
+	// pending counts the steps of a walk that hasn't been confirmed yet.
+	pending   int
+	confirmed bool
+	intervals []time.Duration
+
+	steps   int
+	cadence float64 // steps per minute
+}
+
+func newPedometer(conf Pedometer) *pedometer {
+	return &pedometer{conf: conf}
+}
+
+// update adds a sample of acceleration in m/sec/sec, taken dt seconds after the last one.
+func (p *pedometer) update(now time.Time, accel r3.Vector, dt float64) {
+	magnitude := accel.Norm() / gravity
+	if !p.initialized {
+		p.smoothed, p.baseline = magnitude, magnitude
+		p.initialized = true
+		return
+	}
+	p.smoothed += dt / (stepSmoothingSec + dt) * (magnitude - p.smoothed)
+	p.baseline += dt / (stepBaselineSec + dt) * (magnitude - p.baseline)
+
+	if !p.lastStep.IsZero() && now.Sub(p.lastStep) > maxStepInterval {
+		p.endWalk()
+	}
+
+	signal := p.smoothed - p.baseline
+	if signal < 0 {
+		p.armed = true
+	}
+	if !p.armed || signal < p.conf.threshold() {
+		return
+	}
+	p.armed = false
+	if !p.lastStep.IsZero() && now.Sub(p.lastStep) < minStepInterval {
+		return
+	}
+
+	if !p.lastStep.IsZero() {
+		p.intervals = append(p.intervals, now.Sub(p.lastStep))
+		if len(p.intervals) > cadenceIntervals {
+			p.intervals = p.intervals[1:]
+		}
+	}
+	p.lastStep = now
+	if p.confirmed {
+		p.steps++
+	} else if p.pending++; p.pending >= stepsToConfirm {
+		p.steps += p.pending
+		p.pending = 0
+		p.confirmed = true
+	}
+
+	if p.confirmed && len(p.intervals) > 0 {
+		var total time.Duration
+		for _, interval := range p.intervals {
+			total += interval
+		}
+		p.cadence = float64(time.Minute) * float64(len(p.intervals)) / float64(total)
+	}
+}
+
+func (p *pedometer) endWalk() {
+	p.lastStep = time.Time{}
+	p.pending = 0
+	p.confirmed = false
+	p.intervals = nil
+	p.cadence = 0
+}
+
+func (p *pedometer) activity() string {
+	switch {
+	case p.cadence == 0:
+		return activityStill
+	case p.cadence >= p.conf.runningCadence():
+		return activityRunning
+	default:
+		return activityWalking
+	}
+}
+
+// reset zeroes the step count, leaving any walk in progress running.
+func (p *pedometer) reset() {
+	p.steps = 0
+}
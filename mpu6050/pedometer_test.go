@@ -0,0 +1,69 @@
+package mpu6050
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestPedometer(t *testing.T) {
+	p := newPedometer(Pedometer{})
+	now := time.Now()
+	dt := 0.001
+	// gait feeds the pedometer a vertical bounce at the given step frequency and amplitude in g.
+	gait := func(seconds, stepsPerSecond, amplitude float64) {
+		for i := 0; i < int(seconds/dt); i++ {
+			now = now.Add(time.Millisecond)
+			bounce := amplitude * math.Sin(2*math.Pi*stepsPerSecond*float64(i)*dt)
+			p.update(now, r3.Vector{Z: gravity * (1 + bounce)}, dt)
+		}
+	}
+
+	gait(3, 0, 0)
+	test.That(t, p.steps, test.ShouldEqual, 0)
+	test.That(t, p.activity(), test.ShouldEqual, activityStill)
+
+	// Ten seconds of walking at 2 steps per second. The baseline filter needs a step or two to
+	// settle, so allow for one missed step.
+	gait(10, 2, 0.3)
+	test.That(t, p.steps, test.ShouldBeBetweenOrEqual, 19, 20)
+	test.That(t, p.cadence, test.ShouldAlmostEqual, 120, 1)
+	test.That(t, p.activity(), test.ShouldEqual, activityWalking)
+
+	// Then running at 3 steps per second.
+	before := p.steps
+	gait(10, 3, 0.8)
+	test.That(t, p.steps-before, test.ShouldBeBetweenOrEqual, 29, 30)
+	test.That(t, p.cadence, test.ShouldAlmostEqual, 180, 1)
+	test.That(t, p.activity(), test.ShouldEqual, activityRunning)
+
+	// Stopping ends the walk.
+	gait(3, 0, 0)
+	test.That(t, p.activity(), test.ShouldEqual, activityStill)
+	test.That(t, p.cadence, test.ShouldEqual, 0)
+
+	p.reset()
+	test.That(t, p.steps, test.ShouldEqual, 0)
+}
+
+func TestPedometerIgnoresBumps(t *testing.T) {
+	p := newPedometer(Pedometer{})
+	now := time.Now()
+	dt := 0.001
+	// A jolt every three seconds never adds up to a walk.
+	for i := range 15000 {
+		now = now.Add(time.Millisecond)
+		accel := r3.Vector{Z: gravity}
+		if i%3000 >= 1500 && i%3000 < 1600 {
+			accel.Z = 1.5 * gravity
+		}
+		p.update(now, accel, dt)
+	}
+	test.That(t, p.steps, test.ShouldEqual, 0)
+	test.That(t, p.activity(), test.ShouldEqual, activityStill)
+
+	test.That(t, (&Pedometer{ThresholdG: -1}).validate(), test.ShouldNotBeNil)
+}
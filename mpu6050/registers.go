@@ -0,0 +1,218 @@
+package mpu6050
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Register addresses, from the MPU-6000/MPU-6050 register map.
+const (
+	selfTestXRegister = 13 // SELF_TEST_X through SELF_TEST_Z follow at 14 and 15
+	selfTestARegister = 16
+	// The MPU-6500 keeps its self-test trim in SELF_TEST_X_GYRO through SELF_TEST_Z_GYRO, and
+	// SELF_TEST_X_ACCEL through SELF_TEST_Z_ACCEL, where the MPU-6050 has SELF_TEST_X.
+	selfTestGyro6500Register  = 0
+	selfTestAccel6500Register = 13
+	sampleRateDivRegister     = 25
+	configRegister            = 26
+	gyroConfigRegister        = 27
+	accelConfigRegister       = 28
+	fifoEnableRegister        = 35
+	intPinConfigRegister      = 55
+	intEnableRegister         = 56
+	intStatusRegister         = 58
+	dataRegister              = 59 // ACCEL_XOUT_H, the first of 14 bytes of sensor data
+	temperatureRegister       = 65 // TEMP_OUT_H, followed by TEMP_OUT_L
+	signalPathResetRegister   = 104
+	userControlRegister       = 106
+	powerRegister             = 107
+	power2Register            = 108
+	fifoCountRegister         = 114 // FIFO_COUNT_H, followed by FIFO_COUNT_L
+	fifoDataRegister          = 116
+	defaultAddressRegister    = 117
+
+	// WHO_AM_I is the last register in the map.
+	lastRegister  = defaultAddressRegister
+	registerCount = lastRegister + 1
+)
+
+// bitField is a named group of bits within a register.
+type bitField struct {
+	name  string
+	shift uint
+	width uint
+}
+
+func (field bitField) extract(value byte) int {
+	return int(value>>field.shift) & (1<<field.width - 1)
+}
+
+// registerInfo describes a single register.
+type registerInfo struct {
+	name   string
+	fields []bitField
+	// readOnly registers can never be written. managed registers are configured by the driver, so
+	// writing them from outside the driver will change or break its behavior. Reading a
+	// consumedOnRead register takes data off the chip, as reading FIFO_R_W pops the FIFO.
+	readOnly       bool
+	managed        bool
+	consumedOnRead bool
+}
+
+func bit(name string, shift uint) bitField {
+	return bitField{name: name, shift: shift, width: 1}
+}
+
+func dataRegisterInfo(name string) registerInfo {
+	return registerInfo{name: name, readOnly: true}
+}
+
+var registers = map[byte]registerInfo{
+	selfTestXRegister:     {name: "SELF_TEST_X", fields: []bitField{{"XA_TEST", 5, 3}, {"XG_TEST", 0, 5}}},
+	selfTestXRegister + 1: {name: "SELF_TEST_Y", fields: []bitField{{"YA_TEST", 5, 3}, {"YG_TEST", 0, 5}}},
+	selfTestXRegister + 2: {name: "SELF_TEST_Z", fields: []bitField{{"ZA_TEST", 5, 3}, {"ZG_TEST", 0, 5}}},
+	selfTestARegister:     {name: "SELF_TEST_A", fields: []bitField{{"XA_TEST", 4, 2}, {"YA_TEST", 2, 2}, {"ZA_TEST", 0, 2}}},
+	sampleRateDivRegister: {name: "SMPLRT_DIV", fields: []bitField{{"SMPLRT_DIV", 0, 8}}},
+	configRegister:        {name: "CONFIG", fields: []bitField{{"EXT_SYNC_SET", 3, 3}, {"DLPF_CFG", 0, 3}}},
+	gyroConfigRegister: {
+		name:    "GYRO_CONFIG",
+		fields:  []bitField{bit("XG_ST", 7), bit("YG_ST", 6), bit("ZG_ST", 5), {"FS_SEL", 3, 2}},
+		managed: true,
+	},
+	accelConfigRegister: {
+		name:    "ACCEL_CONFIG",
+		fields:  []bitField{bit("XA_ST", 7), bit("YA_ST", 6), bit("ZA_ST", 5), {"AFS_SEL", 3, 2}},
+		managed: true,
+	},
+	31: {name: "MOT_THR", fields: []bitField{{"MOT_THR", 0, 8}}},
+	fifoEnableRegister: {name: "FIFO_EN", fields: []bitField{
+		bit("TEMP_FIFO_EN", 7), bit("XG_FIFO_EN", 6), bit("YG_FIFO_EN", 5), bit("ZG_FIFO_EN", 4),
+		bit("ACCEL_FIFO_EN", 3), bit("SLV2_FIFO_EN", 2), bit("SLV1_FIFO_EN", 1), bit("SLV0_FIFO_EN", 0),
+	}},
+	36: {name: "I2C_MST_CTRL", fields: []bitField{
+		bit("MULT_MST_EN", 7), bit("WAIT_FOR_ES", 6), bit("SLV_3_FIFO_EN", 5), bit("I2C_MST_P_NSR", 4), {"I2C_MST_CLK", 0, 4},
+	}},
+	intPinConfigRegister: {name: "INT_PIN_CFG", fields: []bitField{
+		bit("INT_LEVEL", 7), bit("INT_OPEN", 6), bit("LATCH_INT_EN", 5), bit("INT_RD_CLEAR", 4),
+		bit("FSYNC_INT_LEVEL", 3), bit("FSYNC_INT_EN", 2), bit("I2C_BYPASS_EN", 1),
+	}},
+	intEnableRegister: {name: "INT_ENABLE", fields: []bitField{
+		bit("MOT_EN", 6), bit("FIFO_OFLOW_EN", 4), bit("I2C_MST_INT_EN", 3), bit("DATA_RDY_EN", 0),
+	}},
+	intStatusRegister: {name: "INT_STATUS", readOnly: true, fields: []bitField{
+		bit("MOT_INT", 6), bit("FIFO_OFLOW_INT", 4), bit("I2C_MST_INT", 3), bit("DATA_RDY_INT", 0),
+	}},
+	dataRegister:      dataRegisterInfo("ACCEL_XOUT_H"),
+	dataRegister + 1:  dataRegisterInfo("ACCEL_XOUT_L"),
+	dataRegister + 2:  dataRegisterInfo("ACCEL_YOUT_H"),
+	dataRegister + 3:  dataRegisterInfo("ACCEL_YOUT_L"),
+	dataRegister + 4:  dataRegisterInfo("ACCEL_ZOUT_H"),
+	dataRegister + 5:  dataRegisterInfo("ACCEL_ZOUT_L"),
+	dataRegister + 6:  dataRegisterInfo("TEMP_OUT_H"),
+	dataRegister + 7:  dataRegisterInfo("TEMP_OUT_L"),
+	dataRegister + 8:  dataRegisterInfo("GYRO_XOUT_H"),
+	dataRegister + 9:  dataRegisterInfo("GYRO_XOUT_L"),
+	dataRegister + 10: dataRegisterInfo("GYRO_YOUT_H"),
+	dataRegister + 11: dataRegisterInfo("GYRO_YOUT_L"),
+	dataRegister + 12: dataRegisterInfo("GYRO_ZOUT_H"),
+	dataRegister + 13: dataRegisterInfo("GYRO_ZOUT_L"),
+	signalPathResetRegister: {name: "SIGNAL_PATH_RESET", fields: []bitField{
+		bit("GYRO_RESET", 2), bit("ACCEL_RESET", 1), bit("TEMP_RESET", 0),
+	}},
+	105: {name: "MOT_DETECT_CTRL", fields: []bitField{{"ACCEL_ON_DELAY", 4, 2}}},
+	userControlRegister: {name: "USER_CTRL", fields: []bitField{
+		bit("FIFO_EN", 6), bit("I2C_MST_EN", 5), bit("I2C_IF_DIS", 4),
+		bit("FIFO_RESET", 2), bit("I2C_MST_RESET", 1), bit("SIG_COND_RESET", 0),
+	}},
+	powerRegister: {
+		name: "PWR_MGMT_1",
+		fields: []bitField{
+			bit("DEVICE_RESET", 7), bit("SLEEP", 6), bit("CYCLE", 5), bit("TEMP_DIS", 3), {"CLKSEL", 0, 3},
+		},
+		managed: true,
+	},
+	power2Register: {name: "PWR_MGMT_2", fields: []bitField{
+		{"LP_WAKE_CTRL", 6, 2}, bit("STBY_XA", 5), bit("STBY_YA", 4), bit("STBY_ZA", 3),
+		bit("STBY_XG", 2), bit("STBY_YG", 1), bit("STBY_ZG", 0),
+	}},
+	fifoCountRegister:      {name: "FIFO_COUNTH", readOnly: true},
+	fifoCountRegister + 1:  {name: "FIFO_COUNTL", readOnly: true},
+	fifoDataRegister:       {name: "FIFO_R_W", consumedOnRead: true},
+	defaultAddressRegister: {name: "WHO_AM_I", readOnly: true, fields: []bitField{{"WHO_AM_I", 1, 6}}},
+}
+
+// registerName returns the datasheet name of a register, or its address in hex if it has none.
+func registerName(register byte) string {
+	if info, ok := registers[register]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("0x%02X", register)
+}
+
+// ConsumedOnRead returns whether reading register takes data off the chip, as reading FIFO_R_W
+// pops the FIFO.
+func ConsumedOnRead(register byte) bool {
+	return registers[register].consumedOnRead
+}
+
+// DecodeRegister returns the name of a register and the values of its named bit fields, keyed in
+// the form "PWR_MGMT_1.SLEEP".
+func DecodeRegister(register, value byte) (string, map[string]int) {
+	name := registerName(register)
+	fields := map[string]int{}
+	for _, field := range registers[register].fields {
+		fields[name+"."+field.name] = field.extract(value)
+	}
+	return name, fields
+}
+
+// parseNumber accepts a JSON number, which arrives as a float64, or a numeric string such as
+// "0x6B".
+func parseNumber(arg interface{}) (int64, error) {
+	switch arg := arg.(type) {
+	case float64:
+		if arg != math.Trunc(arg) {
+			return 0, errors.Errorf("%v is not an integer", arg)
+		}
+		return int64(arg), nil
+	case int:
+		return int64(arg), nil
+	case string:
+		value, err := strconv.ParseInt(arg, 0, 64)
+		if err != nil {
+			return 0, errors.Errorf("%q is not a number", arg)
+		}
+		return value, nil
+	default:
+		return 0, errors.Errorf("expected a number, got %v", arg)
+	}
+}
+
+// parseRegister accepts a register as a number or as a datasheet name such as "PWR_MGMT_1".
+func parseRegister(arg interface{}) (byte, error) {
+	if name, ok := arg.(string); ok {
+		if register, ok := registerByName(name); ok {
+			return register, nil
+		}
+	}
+	value, err := parseNumber(arg)
+	if err != nil {
+		return 0, errors.Wrap(err, "register must be a number or a register name")
+	}
+	if value < 0 || value > lastRegister {
+		return 0, errors.Errorf("register %d is outside the register map (0-%d)", value, lastRegister)
+	}
+	return byte(value), nil
+}
+
+func registerByName(name string) (byte, bool) {
+	for register, info := range registers {
+		if info.name == name {
+			return register, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+package mpu6050
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	goutils "go.viam.com/utils"
+)
+
+const (
+	// Bus utilization is averaged over windows of this length.
+	utilizationWindow = time.Second
+)
+
+// busClient is a device that a busScheduler samples.
+type busClient interface {
+	// readSample reads one sample off the bus.
+	readSample(ctx context.Context) ([]byte, error)
+	// handleSample processes the result of readSample, which was scheduled for now.
+	handleSample(ctx context.Context, data []byte, err error, now time.Time)
+	// setBusUtilization reports the fraction of the time that the client's reads kept the bus busy.
+	setBusUtilization(fraction float64)
+}
+
+type scheduledClient struct {
+	client busClient
+	busy   time.Duration
+}
+
+// busScheduler samples every client on one bus from a single ticker, one after another, so that
+// sensors sharing a bus don't contend for it and each of them is sampled on the same schedule.
+type busScheduler struct {
+	key string
+	// period is the time between samples, which every client on the bus shares.
+	period  time.Duration
+	mu      sync.Mutex
+	clients []*scheduledClient
+	// windowStart is when the current utilization window began.
+	windowStart time.Time
+	workers     *goutils.StoppableWorkers
+}
+
+// schedulers holds the running scheduler for each bus, keyed by the transport's bus key.
+var schedulers = struct {
+	mu    sync.Mutex
+	byBus map[string]*busScheduler
+}{byBus: map[string]*busScheduler{}}
+
+// scheduleOnBus starts sampling client every period along with every other client on the same
+// bus. The bus's first client sets its period, and the others must match it.
+func scheduleOnBus(key string, period time.Duration, client busClient) error {
+	schedulers.mu.Lock()
+	defer schedulers.mu.Unlock()
+	scheduler, ok := schedulers.byBus[key]
+	if !ok {
+		scheduler = &busScheduler{key: key, period: period, windowStart: time.Now()}
+		scheduler.workers = goutils.NewBackgroundStoppableWorkers(scheduler.run)
+		schedulers.byBus[key] = scheduler
+	} else if scheduler.period != period {
+		return errors.Errorf("the other sensors on bus %s are sampled every %s, so this one can't be sampled every %s",
+			key, scheduler.period, period)
+	}
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	scheduler.clients = append(scheduler.clients, &scheduledClient{client: client})
+	return nil
+}
+
+// unscheduleFromBus stops sampling client. Once it returns, the client won't be called again. The
+// bus's scheduler stops once it has no clients left.
+func unscheduleFromBus(key string, client busClient) {
+	schedulers.mu.Lock()
+	defer schedulers.mu.Unlock()
+	scheduler, ok := schedulers.byBus[key]
+	if !ok {
+		return
+	}
+	scheduler.mu.Lock()
+	for i, scheduled := range scheduler.clients {
+		if scheduled.client == client {
+			scheduler.clients = append(scheduler.clients[:i], scheduler.clients[i+1:]...)
+			break
+		}
+	}
+	empty := len(scheduler.clients) == 0
+	scheduler.mu.Unlock()
+
+	if empty {
+		scheduler.workers.Stop()
+		delete(schedulers.byBus, key)
+	}
+}
+
+func (bs *busScheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(bs.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			bs.sample(ctx, now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sample reads every client once. Every client's sample is stamped with the tick time rather than
+// the time its read finished, so the spacing between samples stays even however long the other
+// clients' reads take.
+func (bs *busScheduler) sample(ctx context.Context, now time.Time) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	for _, scheduled := range bs.clients {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		data, err := scheduled.client.readSample(ctx)
+		scheduled.busy += time.Since(start)
+		scheduled.client.handleSample(ctx, data, err, now)
+	}
+
+	if elapsed := now.Sub(bs.windowStart); elapsed >= utilizationWindow {
+		for _, scheduled := range bs.clients {
+			scheduled.client.setBusUtilization(float64(scheduled.busy) / float64(elapsed))
+			scheduled.busy = 0
+		}
+		bs.windowStart = now
+	}
+}
@@ -0,0 +1,84 @@
+package mpu6050
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+// fakeBusClient records the samples it is given.
+type fakeBusClient struct {
+	mu          sync.Mutex
+	readTime    time.Duration
+	times       []time.Time
+	utilization float64
+}
+
+func (c *fakeBusClient) readSample(ctx context.Context) ([]byte, error) {
+	time.Sleep(c.readTime)
+	return nil, nil
+}
+
+func (c *fakeBusClient) handleSample(ctx context.Context, data []byte, err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times = append(c.times, now)
+}
+
+func (c *fakeBusClient) setBusUtilization(fraction float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.utilization = fraction
+}
+
+func (c *fakeBusClient) samples() ([]time.Time, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Time(nil), c.times...), c.utilization
+}
+
+func TestBusScheduler(t *testing.T) {
+	const key = "test:bus"
+	fast := &fakeBusClient{}
+	slow := &fakeBusClient{readTime: 200 * time.Microsecond}
+	other := &fakeBusClient{}
+	test.That(t, scheduleOnBus(key, time.Millisecond, fast), test.ShouldBeNil)
+	test.That(t, scheduleOnBus(key, time.Millisecond, slow), test.ShouldBeNil)
+	test.That(t, scheduleOnBus("test:other", time.Millisecond, other), test.ShouldBeNil)
+	// Every client on a bus is sampled at the same rate.
+	test.That(t, scheduleOnBus(key, time.Second/8000, &fakeBusClient{}), test.ShouldNotBeNil)
+
+	schedulers.mu.Lock()
+	test.That(t, schedulers.byBus[key].clients, test.ShouldHaveLength, 2)
+	schedulers.mu.Unlock()
+
+	// Clients on the same bus are sampled on the same ticks, and each reports its own share of the
+	// bus.
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		_, utilization := slow.samples()
+		test.That(tb, utilization, test.ShouldBeGreaterThan, 0)
+	})
+	unscheduleFromBus(key, slow)
+	slowTimes, slowUtilization := slow.samples()
+	fastTimes, fastUtilization := fast.samples()
+	test.That(t, fastTimes[:len(slowTimes)], test.ShouldResemble, slowTimes)
+	test.That(t, slowUtilization, test.ShouldBeGreaterThan, fastUtilization)
+	test.That(t, slowUtilization, test.ShouldBeLessThan, 1)
+
+	// Once unscheduled, a client isn't sampled again, and the scheduler stops with its last client.
+	time.Sleep(10 * time.Millisecond)
+	after, _ := slow.samples()
+	test.That(t, after, test.ShouldHaveLength, len(slowTimes))
+	unscheduleFromBus(key, fast)
+	unscheduleFromBus("test:other", other)
+	schedulers.mu.Lock()
+	test.That(t, schedulers.byBus, test.ShouldNotContainKey, key)
+	schedulers.mu.Unlock()
+
+	otherTimes, _ := other.samples()
+	test.That(t, otherTimes, test.ShouldNotBeEmpty)
+}
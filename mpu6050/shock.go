@@ -0,0 +1,292 @@
+package mpu6050
+
+import (
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultShockWindowSec   = 1.0
+	defaultShockPreTrigger  = 50
+	defaultShockPostTrigger = 100
+	defaultShockMaxEvents   = 20
+	maxShockTriggerSamples  = 5000
+	maxShockEventSamples    = 10000
+)
+
+// Shock configures peak acceleration tracking and shock event capture.
+type Shock struct {
+	// ThresholdG is the acceleration magnitude, in g and including gravity, that triggers a shock
+	// event. A sensor at rest reads 1g, so this should be well above 1.
+	ThresholdG float64 `json:"threshold_g"`
+	// WindowSec is the length of the windows that peaks, RMS, and jerk are summarized over.
+	WindowSec float64 `json:"window_sec,omitempty"`
+	// PreTriggerSamples and PostTriggerSamples are how many samples to keep from before a shock
+	// starts and after it ends. Samples arrive at about 1kHz.
+	PreTriggerSamples  int `json:"pre_trigger_samples,omitempty"`
+	PostTriggerSamples int `json:"post_trigger_samples,omitempty"`
+	// MaxEvents is how many events to keep; the oldest are dropped first.
+	MaxEvents int `json:"max_events,omitempty"`
+}
+
+func (s *Shock) validate() error {
+	if s.ThresholdG <= 0 {
+		return errors.New("shock.threshold_g must be positive")
+	}
+	if s.WindowSec < 0 {
+		return errors.Errorf("shock.window_sec must not be negative, got %g", s.WindowSec)
+	}
+	if s.PreTriggerSamples < 0 || s.PreTriggerSamples > maxShockTriggerSamples ||
+		s.PostTriggerSamples < 0 || s.PostTriggerSamples > maxShockTriggerSamples {
+		return errors.Errorf("shock pre- and post-trigger samples must be between 0 and %d", maxShockTriggerSamples)
+	}
+	if s.MaxEvents < 0 {
+		return errors.Errorf("shock.max_events must not be negative, got %d", s.MaxEvents)
+	}
+	return nil
+}
+
+func (s *Shock) windowDuration() time.Duration {
+	seconds := s.WindowSec
+	if seconds == 0 {
+		seconds = defaultShockWindowSec
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (s *Shock) preTrigger() int {
+	if s.PreTriggerSamples == 0 {
+		return defaultShockPreTrigger
+	}
+	return s.PreTriggerSamples
+}
+
+func (s *Shock) postTrigger() int {
+	if s.PostTriggerSamples == 0 {
+		return defaultShockPostTrigger
+	}
+	return s.PostTriggerSamples
+}
+
+func (s *Shock) maxEvents() int {
+	if s.MaxEvents == 0 {
+		return defaultShockMaxEvents
+	}
+	return s.MaxEvents
+}
+
+// shockStats summarizes the acceleration over a window. Peaks are in g and RMS in g, with the
+// window's mean (mostly gravity) removed from the RMS; jerk is in m/sec/sec/sec.
+type shockStats struct {
+	start         time.Time
+	peak          r3.Vector
+	peakMagnitude float64
+	rms           float64
+	maxJerk       float64
+	samples       int
+}
+
+// shockSample is one acceleration sample in m/sec/sec, captured as part of a shock event.
+type shockSample struct {
+	time  time.Time
+	accel r3.Vector
+}
+
+// shockEvent is a period when the acceleration magnitude exceeded the threshold, along with the
+// samples around it.
+type shockEvent struct {
+	start, end    time.Time
+	peakMagnitude float64 // g
+	peak          r3.Vector
+	samples       []shockSample
+	// triggerIndex is the index in samples of the first sample over the threshold.
+	triggerIndex int
+}
+
+// shockMonitor tracks peak acceleration in the background and records shock events.
+type shockMonitor struct {
+	conf Shock
+
+	// The window being accumulated, with running sums for the per-axis variance.
+	current  shockStats
+	sum      r3.Vector
+	sumSq    r3.Vector
+	previous shockSample
+	// last is the most recently completed window, and sinceReset covers everything since the
+	// monitor was created or reset.
+	last       *shockStats
+	sinceReset shockStats
+
+	// history holds the most recent samples, to provide the pre-trigger part of an event.
+	history    []shockSample
+	historyPos int
+	// active is the event being captured, and quietSamples counts the samples since its
+	// acceleration last exceeded the threshold.
+	active       *shockEvent
+	quietSamples int
+	events       []*shockEvent
+	totalEvents  int
+}
+
+func newShockMonitor(conf Shock) *shockMonitor {
+	return &shockMonitor{conf: conf, history: make([]shockSample, 0, conf.preTrigger())}
+}
+
+// update adds a sample of acceleration in m/sec/sec. It returns any shock event that this sample
+// completed.
+func (sm *shockMonitor) update(now time.Time, accel r3.Vector) *shockEvent {
+	sample := shockSample{time: now, accel: accel}
+	sm.updateStats(sample)
+	finished := sm.updateEvents(sample)
+	sm.previous = sample
+	return finished
+}
+
+func (sm *shockMonitor) updateStats(sample shockSample) {
+	if sm.current.samples == 0 {
+		sm.current = shockStats{start: sample.time}
+		sm.sum, sm.sumSq = r3.Vector{}, r3.Vector{}
+	}
+	if sm.sinceReset.start.IsZero() {
+		sm.sinceReset.start = sample.time
+	}
+
+	inG := sample.accel.Mul(1 / gravity)
+	magnitude := inG.Norm()
+	var jerk float64
+	if !sm.previous.time.IsZero() {
+		if dt := sample.time.Sub(sm.previous.time).Seconds(); dt > 0 {
+			jerk = sample.accel.Sub(sm.previous.accel).Norm() / dt
+		}
+	}
+	for _, stats := range []*shockStats{&sm.current, &sm.sinceReset} {
+		stats.peak = maxComponents(stats.peak, inG.Abs())
+		stats.peakMagnitude = math.Max(stats.peakMagnitude, magnitude)
+		stats.maxJerk = math.Max(stats.maxJerk, jerk)
+		stats.samples++
+	}
+	sm.sum = sm.sum.Add(inG)
+	sm.sumSq = sm.sumSq.Add(r3.Vector{X: inG.X * inG.X, Y: inG.Y * inG.Y, Z: inG.Z * inG.Z})
+
+	if sample.time.Sub(sm.current.start) >= sm.conf.windowDuration() {
+		n := float64(sm.current.samples)
+		mean := sm.sum.Mul(1 / n)
+		meanSq := sm.sumSq.Mul(1 / n)
+		variance := meanSq.Sub(r3.Vector{X: mean.X * mean.X, Y: mean.Y * mean.Y, Z: mean.Z * mean.Z})
+		sm.current.rms = math.Sqrt(math.Max(0, variance.X+variance.Y+variance.Z))
+		completed := sm.current
+		sm.last = &completed
+		sm.current = shockStats{}
+	}
+}
+
+func (sm *shockMonitor) updateEvents(sample shockSample) *shockEvent {
+	magnitude := sample.accel.Norm() / gravity
+	over := magnitude >= sm.conf.ThresholdG
+
+	var finished *shockEvent
+	switch {
+	case sm.active != nil:
+		sm.active.samples = append(sm.active.samples, sample)
+		if over {
+			sm.quietSamples = 0
+			sm.active.end = sample.time
+			sm.active.peak = maxComponents(sm.active.peak, sample.accel.Mul(1/gravity).Abs())
+			sm.active.peakMagnitude = math.Max(sm.active.peakMagnitude, magnitude)
+		} else {
+			sm.quietSamples++
+		}
+		if sm.quietSamples >= sm.conf.postTrigger() || len(sm.active.samples) >= maxShockEventSamples {
+			finished = sm.active
+			sm.events = append(sm.events, finished)
+			if len(sm.events) > sm.conf.maxEvents() {
+				sm.events = sm.events[len(sm.events)-sm.conf.maxEvents():]
+			}
+			sm.active = nil
+			sm.history = sm.history[:0]
+			sm.historyPos = 0
+		}
+	case over:
+		// Unroll the ring buffer so the pre-trigger samples are in order.
+		samples := make([]shockSample, 0, len(sm.history)+sm.conf.postTrigger()+1)
+		samples = append(samples, sm.history[sm.historyPos:]...)
+		samples = append(samples, sm.history[:sm.historyPos]...)
+		sm.active = &shockEvent{
+			start:         sample.time,
+			end:           sample.time,
+			peakMagnitude: magnitude,
+			peak:          sample.accel.Mul(1 / gravity).Abs(),
+			samples:       append(samples, sample),
+			triggerIndex:  len(samples),
+		}
+		sm.quietSamples = 0
+		sm.totalEvents++
+	default:
+		if len(sm.history) < cap(sm.history) {
+			sm.history = append(sm.history, sample)
+		} else if cap(sm.history) > 0 {
+			sm.history[sm.historyPos] = sample
+			sm.historyPos = (sm.historyPos + 1) % cap(sm.history)
+		}
+	}
+	return finished
+}
+
+// reset clears the peaks since reset and every recorded event.
+func (sm *shockMonitor) reset() {
+	sm.sinceReset = shockStats{}
+	sm.events = nil
+	sm.totalEvents = 0
+	if sm.active != nil {
+		// Keep capturing the event in progress, but count it afresh.
+		sm.totalEvents = 1
+	}
+}
+
+// readings reports the most recent window and the peak since reset.
+func (sm *shockMonitor) readings() map[string]interface{} {
+	readings := map[string]interface{}{
+		"shock_events":                 sm.totalEvents,
+		"peak_magnitude_since_reset_g": sm.sinceReset.peakMagnitude,
+	}
+	if sm.last != nil {
+		readings["peak_x_g"] = sm.last.peak.X
+		readings["peak_y_g"] = sm.last.peak.Y
+		readings["peak_z_g"] = sm.last.peak.Z
+		readings["peak_magnitude_g"] = sm.last.peakMagnitude
+		readings["rms_g"] = sm.last.rms
+		readings["max_jerk_mps3"] = sm.last.maxJerk
+	}
+	return readings
+}
+
+// toMap describes an event for DoCommand. Sample times are in milliseconds relative to the first
+// sample over the threshold, and accelerations in m/sec/sec.
+func (event *shockEvent) toMap() map[string]interface{} {
+	trigger := event.samples[event.triggerIndex].time
+	samples := make([]interface{}, 0, len(event.samples))
+	for _, sample := range event.samples {
+		samples = append(samples, map[string]interface{}{
+			"t_ms": float64(sample.time.Sub(trigger)) / float64(time.Millisecond),
+			"x":    sample.accel.X,
+			"y":    sample.accel.Y,
+			"z":    sample.accel.Z,
+		})
+	}
+	return map[string]interface{}{
+		"start":            event.start.Format(time.RFC3339Nano),
+		"duration_ms":      float64(event.end.Sub(event.start)) / float64(time.Millisecond),
+		"peak_magnitude_g": event.peakMagnitude,
+		"peak_x_g":         event.peak.X,
+		"peak_y_g":         event.peak.Y,
+		"peak_z_g":         event.peak.Z,
+		"samples":          samples,
+	}
+}
+
+func maxComponents(a, b r3.Vector) r3.Vector {
+	return r3.Vector{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y), Z: math.Max(a.Z, b.Z)}
+}
@@ -0,0 +1,89 @@
+package mpu6050
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestShockMonitor(t *testing.T) {
+	conf := Shock{ThresholdG: 3, WindowSec: 0.1, PreTriggerSamples: 10, PostTriggerSamples: 20}
+	test.That(t, conf.validate(), test.ShouldBeNil)
+	monitor := newShockMonitor(conf)
+
+	start := time.Now()
+	now := start
+	var finished []*shockEvent
+	feed := func(count int, accel r3.Vector) {
+		for range count {
+			now = now.Add(time.Millisecond)
+			if event := monitor.update(now, accel); event != nil {
+				finished = append(finished, event)
+			}
+		}
+	}
+
+	rest := r3.Vector{Z: gravity}
+	feed(150, rest)
+	test.That(t, monitor.last, test.ShouldNotBeNil)
+	test.That(t, monitor.last.peakMagnitude, test.ShouldAlmostEqual, 1)
+	test.That(t, monitor.last.rms, test.ShouldAlmostEqual, 0, 1e-6)
+	test.That(t, monitor.last.maxJerk, test.ShouldEqual, 0)
+
+	// A 5g knock, mostly along X, for 5ms.
+	knock := r3.Vector{X: 4 * gravity, Z: 3 * gravity}
+	feed(5, knock)
+	test.That(t, monitor.active, test.ShouldNotBeNil)
+	test.That(t, finished, test.ShouldBeEmpty)
+	feed(19, rest)
+	test.That(t, finished, test.ShouldBeEmpty)
+	feed(1, rest)
+	test.That(t, len(finished), test.ShouldEqual, 1)
+
+	event := finished[0]
+	test.That(t, event.peakMagnitude, test.ShouldAlmostEqual, 5)
+	test.That(t, event.peak.X, test.ShouldAlmostEqual, 4)
+	test.That(t, event.end.Sub(event.start), test.ShouldEqual, 4*time.Millisecond)
+	test.That(t, len(event.samples), test.ShouldEqual, 10+5+20)
+	test.That(t, event.triggerIndex, test.ShouldEqual, 10)
+	test.That(t, event.samples[event.triggerIndex].accel, test.ShouldResemble, knock)
+	test.That(t, event.samples[event.triggerIndex-1].accel, test.ShouldResemble, rest)
+	test.That(t, event.toMap()["samples"].([]interface{})[0].(map[string]interface{})["t_ms"], test.ShouldAlmostEqual, -10)
+
+	// Finish the window the knock was in and check its statistics.
+	feed(100, rest)
+	test.That(t, monitor.sinceReset.peakMagnitude, test.ShouldAlmostEqual, 5)
+	test.That(t, monitor.sinceReset.maxJerk, test.ShouldAlmostEqual, knock.Sub(rest).Norm()/0.001, 1e-3)
+	readings := monitor.readings()
+	test.That(t, readings["shock_events"], test.ShouldEqual, 1)
+	test.That(t, readings["peak_magnitude_since_reset_g"], test.ShouldAlmostEqual, 5)
+
+	monitor.reset()
+	test.That(t, monitor.events, test.ShouldBeEmpty)
+	test.That(t, monitor.readings()["shock_events"], test.ShouldEqual, 0)
+	test.That(t, monitor.readings()["peak_magnitude_since_reset_g"], test.ShouldEqual, 0)
+}
+
+func TestShockEventLimit(t *testing.T) {
+	monitor := newShockMonitor(Shock{ThresholdG: 2, PreTriggerSamples: 1, PostTriggerSamples: 1, MaxEvents: 3})
+	now := time.Now()
+	for i := range 10 {
+		for _, accel := range []r3.Vector{{Z: gravity}, {Z: float64(3+i) * gravity}, {Z: gravity}} {
+			now = now.Add(time.Millisecond)
+			monitor.update(now, accel)
+		}
+	}
+	test.That(t, monitor.totalEvents, test.ShouldEqual, 10)
+	test.That(t, len(monitor.events), test.ShouldEqual, 3)
+	// The newest events are kept.
+	test.That(t, monitor.events[2].peakMagnitude, test.ShouldAlmostEqual, 12)
+}
+
+func TestShockValidation(t *testing.T) {
+	test.That(t, (&Shock{}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Shock{ThresholdG: 2, WindowSec: -1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Shock{ThresholdG: 2, PreTriggerSamples: 100000}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Shock{ThresholdG: 2, MaxEvents: -1}).validate(), test.ShouldNotBeNil)
+}
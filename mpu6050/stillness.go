@@ -0,0 +1,178 @@
+package mpu6050
+
+import (
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Defaults for the stillness detector. The thresholds are standard deviations over the window,
+	// comfortably above the chip's noise with its filter off.
+	defaultStillnessWindow  = 200  // samples, about 0.2 seconds
+	defaultStillGyroStdDev  = 0.5  // degrees per second
+	defaultStillAccelStdDev = 0.15 // m/sec/sec
+	defaultStillMaxRate     = 10   // degrees per second
+
+	// The sensor must be this far out of its still thresholds before it counts as moving, so that
+	// noise near a threshold doesn't flap the state.
+	stillnessExitFactor = 2
+	// Constant acceleration has no variance, so the average magnitude must also be near 1g.
+	stillnessAccelTolerance = 0.3 // m/sec/sec
+
+	motionStateStill  = "still"
+	motionStateMoving = "moving"
+)
+
+// Stillness configures the stillness detector, which decides when the sensor is stationary from
+// the variance of its readings over a sliding window. It always runs, because zero-velocity
+// updates, noise measurement, and the tilt stable flag depend on it.
+type Stillness struct {
+	WindowSamples int `json:"window_samples,omitempty"`
+	// GyroThreshold and AccelThreshold are the standard deviations over the window below which the
+	// sensor is still.
+	GyroThreshold  float64 `json:"gyro_threshold_dps,omitempty"`
+	AccelThreshold float64 `json:"accel_threshold_mps2,omitempty"`
+	// MaxRate is the largest average angular velocity the sensor can read while still. Steady
+	// rotation has no variance, so this keeps it from looking still, but it has to allow for any
+	// uncorrected gyroscope bias.
+	MaxRate float64 `json:"max_rate_dps,omitempty"`
+	// RefineGyroBias is the original way to turn on online gyroscope bias estimation from still
+	// readings. It is kept for existing configs, and is the same as setting GyroBias with its
+	// defaults.
+	RefineGyroBias bool `json:"refine_gyro_bias,omitempty"`
+}
+
+func (s *Stillness) validate() error {
+	if s.WindowSamples < 0 || s.GyroThreshold < 0 || s.AccelThreshold < 0 || s.MaxRate < 0 {
+		return errors.New("stillness settings must not be negative")
+	}
+	if s.WindowSamples == 1 {
+		return errors.New("stillness.window_samples must be at least 2")
+	}
+	return nil
+}
+
+func (s *Stillness) windowSamples() int {
+	if s.WindowSamples == 0 {
+		return defaultStillnessWindow
+	}
+	return s.WindowSamples
+}
+
+func (s *Stillness) gyroThreshold() float64 {
+	if s.GyroThreshold == 0 {
+		return defaultStillGyroStdDev
+	}
+	return s.GyroThreshold
+}
+
+func (s *Stillness) accelThreshold() float64 {
+	if s.AccelThreshold == 0 {
+		return defaultStillAccelStdDev
+	}
+	return s.AccelThreshold
+}
+
+func (s *Stillness) maxRate() float64 {
+	if s.MaxRate == 0 {
+		return defaultStillMaxRate
+	}
+	return s.MaxRate
+}
+
+// stillnessDetector decides whether the sensor is sitting still.
+type stillnessDetector struct {
+	conf Stillness
+
+	// window holds the most recent samples: accelerometer X, Y, Z in m/sec/sec, then gyroscope X,
+	// Y, Z in degrees per second. sum and sumSq are running sums over it.
+	window [][6]float64
+	pos    int
+	sum    [6]float64
+	sumSq  [6]float64
+
+	still bool
+	// since is when the sensor entered its current state.
+	since time.Time
+}
+
+func newStillnessDetector(conf Stillness) *stillnessDetector {
+	return &stillnessDetector{conf: conf, window: make([][6]float64, 0, conf.windowSamples())}
+}
+
+// update takes acceleration in m/sec/sec and angular velocity in degrees per second, and returns
+// whether the sensor is still.
+func (sd *stillnessDetector) update(now time.Time, accel, gyro r3.Vector) bool {
+	if sd.since.IsZero() {
+		sd.since = now
+	}
+
+	sample := [6]float64{accel.X, accel.Y, accel.Z, gyro.X, gyro.Y, gyro.Z}
+	if len(sd.window) < cap(sd.window) {
+		sd.window = append(sd.window, sample)
+		for i, value := range sample {
+			sd.sum[i] += value
+			sd.sumSq[i] += value * value
+		}
+		if len(sd.window) < cap(sd.window) {
+			return sd.still
+		}
+	} else {
+		old := sd.window[sd.pos]
+		sd.window[sd.pos] = sample
+		sd.pos = (sd.pos + 1) % len(sd.window)
+		if sd.pos == 0 {
+			// Start the running sums afresh once per window, so rounding errors can't build up.
+			sd.sum, sd.sumSq = [6]float64{}, [6]float64{}
+			for _, s := range sd.window {
+				for i, value := range s {
+					sd.sum[i] += value
+					sd.sumSq[i] += value * value
+				}
+			}
+		} else {
+			for i, value := range sample {
+				sd.sum[i] += value - old[i]
+				sd.sumSq[i] += value*value - old[i]*old[i]
+			}
+		}
+	}
+
+	n := float64(len(sd.window))
+	var mean, variance [6]float64
+	for i := range mean {
+		mean[i] = sd.sum[i] / n
+		variance[i] = math.Max(0, sd.sumSq[i]/n-mean[i]*mean[i])
+	}
+	accelStdDev := math.Sqrt(variance[0] + variance[1] + variance[2])
+	gyroStdDev := math.Sqrt(variance[3] + variance[4] + variance[5])
+	accelError := math.Abs(r3.Vector{X: mean[0], Y: mean[1], Z: mean[2]}.Norm() - gravity)
+	rate := r3.Vector{X: mean[3], Y: mean[4], Z: mean[5]}.Norm()
+
+	// Once still, the sensor has to move clearly more than the thresholds to count as moving.
+	factor := 1.0
+	if sd.still {
+		factor = stillnessExitFactor
+	}
+	still := gyroStdDev < factor*sd.conf.gyroThreshold() &&
+		accelStdDev < factor*sd.conf.accelThreshold() &&
+		accelError < factor*stillnessAccelTolerance &&
+		rate < factor*sd.conf.maxRate()
+	if still != sd.still {
+		sd.still = still
+		sd.since = now
+	}
+	return sd.still
+}
+
+// state returns the motion state and how long the sensor has been in it.
+func (sd *stillnessDetector) state(now time.Time) (string, time.Duration) {
+	state := motionStateMoving
+	if sd.still {
+		state = motionStateStill
+	}
+	return state, now.Sub(sd.since)
+}
@@ -0,0 +1,89 @@
+package mpu6050
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestStillnessDetector(t *testing.T) {
+	sd := newStillnessDetector(Stillness{})
+	start := time.Now()
+	now := start
+	at := r3.Vector{Z: gravity}
+
+	// Noise well inside the thresholds is still, but only once the window has filled.
+	feed := func(n int, sample func(i int) (r3.Vector, r3.Vector)) bool {
+		var still bool
+		for i := range n {
+			now = now.Add(time.Millisecond)
+			accel, gyro := sample(i)
+			still = sd.update(now, accel, gyro)
+		}
+		return still
+	}
+	quiet := func(i int) (r3.Vector, r3.Vector) {
+		wobble := math.Sin(float64(i))
+		return at.Add(r3.Vector{X: 0.05 * wobble}), r3.Vector{Y: 0.2 * wobble}
+	}
+	test.That(t, feed(defaultStillnessWindow-1, quiet), test.ShouldBeFalse)
+	test.That(t, feed(1, quiet), test.ShouldBeTrue)
+	state, _ := sd.state(now)
+	test.That(t, state, test.ShouldEqual, motionStateStill)
+	stillSince := now
+
+	// Noise a little over the threshold doesn't end stillness, but clearly more does.
+	test.That(t, feed(defaultStillnessWindow, func(i int) (r3.Vector, r3.Vector) {
+		return at, r3.Vector{Y: 0.9 * math.Sin(float64(i))}
+	}), test.ShouldBeTrue)
+	state, inState := sd.state(now)
+	test.That(t, state, test.ShouldEqual, motionStateStill)
+	test.That(t, inState, test.ShouldEqual, now.Sub(stillSince))
+	test.That(t, feed(defaultStillnessWindow, func(i int) (r3.Vector, r3.Vector) {
+		return at, r3.Vector{Y: 5 * math.Sin(float64(i))}
+	}), test.ShouldBeFalse)
+
+	// Once moving, the same slightly noisy signal isn't still.
+	test.That(t, feed(defaultStillnessWindow, func(i int) (r3.Vector, r3.Vector) {
+		return at, r3.Vector{Y: 0.9 * math.Sin(float64(i))}
+	}), test.ShouldBeFalse)
+	state, inState = sd.state(now)
+	test.That(t, state, test.ShouldEqual, motionStateMoving)
+	test.That(t, inState, test.ShouldBeGreaterThan, 0)
+}
+
+func TestStillnessSteadyMotion(t *testing.T) {
+	now := time.Now()
+	feed := func(sd *stillnessDetector, accel, gyro r3.Vector) bool {
+		var still bool
+		for range defaultStillnessWindow {
+			now = now.Add(time.Millisecond)
+			still = sd.update(now, accel, gyro)
+		}
+		return still
+	}
+
+	// Neither constant acceleration nor steady rotation has any variance, but neither is still.
+	test.That(t, feed(newStillnessDetector(Stillness{}), r3.Vector{X: 3, Z: gravity}, r3.Vector{}), test.ShouldBeFalse)
+	test.That(t, feed(newStillnessDetector(Stillness{}), r3.Vector{Z: gravity}, r3.Vector{Z: 30}), test.ShouldBeFalse)
+	// A small uncorrected gyroscope bias is still.
+	test.That(t, feed(newStillnessDetector(Stillness{}), r3.Vector{Z: gravity}, r3.Vector{Z: 2}), test.ShouldBeTrue)
+	// A sensor tilted in any direction is still.
+	test.That(t, feed(newStillnessDetector(Stillness{}), r3.Vector{X: gravity / math.Sqrt2, Y: -gravity / math.Sqrt2}, r3.Vector{}),
+		test.ShouldBeTrue)
+	// A shorter window decides sooner.
+	short := newStillnessDetector(Stillness{WindowSamples: 10})
+	now = now.Add(time.Millisecond)
+	for range 10 {
+		now = now.Add(time.Millisecond)
+		short.update(now, r3.Vector{Z: gravity}, r3.Vector{})
+	}
+	test.That(t, short.still, test.ShouldBeTrue)
+
+	test.That(t, (&Stillness{WindowSamples: 1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Stillness{GyroThreshold: -1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Stillness{}).validate(), test.ShouldBeNil)
+}
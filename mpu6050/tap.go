@@ -0,0 +1,204 @@
+package mpu6050
+
+import (
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTapThreshold   = 1.5 // g
+	defaultTapDuration    = 50 * time.Millisecond
+	defaultTapLatency     = 100 * time.Millisecond
+	defaultTapWindow      = 300 * time.Millisecond
+	defaultTapQueueLength = 32
+
+	// The baseline that taps are measured against follows the acceleration with this time
+	// constant, so that gravity and slow movements don't count.
+	tapBaselineSec = 0.2
+
+	tapEventSingle = "tap"
+	tapEventDouble = "double_tap"
+)
+
+// Tap configures software tap and double-tap detection. A tap is a spike in acceleration above
+// the threshold that is over within the duration. After a tap, nothing is detected for the latency,
+// and a second tap that starts within the window after the first is also reported as a double tap.
+type Tap struct {
+	ThresholdG    float64 `json:"threshold_g,omitempty"`
+	DurationMs    float64 `json:"duration_ms,omitempty"`
+	LatencyMs     float64 `json:"latency_ms,omitempty"`
+	WindowMs      float64 `json:"window_ms,omitempty"`
+	QueueLength   int     `json:"queue_length,omitempty"`
+	DisableDouble bool    `json:"disable_double_tap,omitempty"`
+}
+
+func (t *Tap) validate() error {
+	if t.ThresholdG < 0 || t.DurationMs < 0 || t.LatencyMs < 0 || t.WindowMs < 0 || t.QueueLength < 0 {
+		return errors.New("tap settings must not be negative")
+	}
+	if t.window() <= t.latency() {
+		return errors.Errorf("tap.window_ms (%s) must be longer than tap.latency_ms (%s)", t.window(), t.latency())
+	}
+	return nil
+}
+
+func millisecondsOrDefault(ms float64, defaultDuration time.Duration) time.Duration {
+	if ms == 0 {
+		return defaultDuration
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func (t *Tap) threshold() float64 {
+	if t.ThresholdG == 0 {
+		return defaultTapThreshold
+	}
+	return t.ThresholdG
+}
+
+func (t *Tap) duration() time.Duration {
+	return millisecondsOrDefault(t.DurationMs, defaultTapDuration)
+}
+
+func (t *Tap) latency() time.Duration {
+	return millisecondsOrDefault(t.LatencyMs, defaultTapLatency)
+}
+
+func (t *Tap) window() time.Duration {
+	return millisecondsOrDefault(t.WindowMs, defaultTapWindow)
+}
+
+func (t *Tap) queueLength() int {
+	if t.QueueLength == 0 {
+		return defaultTapQueueLength
+	}
+	return t.QueueLength
+}
+
+// tapEvent is a detected tap. axis and direction give the direction of the strongest acceleration
+// in the spike, which is the direction the sensor was pushed.
+type tapEvent struct {
+	kind      string
+	time      time.Time
+	axis      string
+	direction string
+	peak      float64 // g, above the baseline
+}
+
+func (event tapEvent) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"type":      event.kind,
+		"time":      event.time.Format(time.RFC3339Nano),
+		"axis":      event.axis,
+		"direction": event.direction,
+		"peak_g":    event.peak,
+	}
+}
+
+// tapDetector finds taps in the acceleration stream.
+type tapDetector struct {
+	conf Tap
+
+	baseline    r3.Vector // m/sec/sec
+	initialized bool
+
+	// spikeStart is when the acceleration crossed the threshold, or zero if it is below it.
+	// spikeTooLong is set once a spike outlasts the tap duration, and spikePeak holds its largest
+	// deviation from the baseline.
+	spikeStart   time.Time
+	spikeTooLong bool
+	spikePeak    r3.Vector
+
+	// lastTap is the start of the most recent tap, and pendingDouble whether it could still become
+	// the first half of a double tap.
+	lastTap       time.Time
+	pendingDouble bool
+
+	queue      []tapEvent
+	dropped    int
+	taps       int
+	doubleTaps int
+}
+
+func newTapDetector(conf Tap) *tapDetector {
+	return &tapDetector{conf: conf}
+}
+
+// update adds a sample of acceleration in m/sec/sec, taken dt seconds after the last one.
+func (td *tapDetector) update(now time.Time, accel r3.Vector, dt float64) {
+	if !td.initialized {
+		td.baseline = accel
+		td.initialized = true
+		return
+	}
+
+	deviation := accel.Sub(td.baseline)
+	over := deviation.Norm()/gravity >= td.conf.threshold()
+	if !over {
+		if !td.spikeStart.IsZero() && !td.spikeTooLong {
+			td.detected(td.spikeStart, td.spikePeak)
+		}
+		td.spikeStart = time.Time{}
+		td.spikeTooLong = false
+		// Only follow the baseline between spikes, so a tap doesn't leak into it.
+		td.baseline = td.baseline.Add(deviation.Mul(dt / (tapBaselineSec + dt)))
+		return
+	}
+
+	if td.spikeStart.IsZero() {
+		td.spikeStart = now
+		td.spikePeak = deviation
+		// Spikes that start during the latency after a tap are its ringing, not a new tap.
+		td.spikeTooLong = !td.lastTap.IsZero() && now.Sub(td.lastTap) < td.conf.latency()
+	}
+	if deviation.Norm() > td.spikePeak.Norm() {
+		td.spikePeak = deviation
+	}
+	if now.Sub(td.spikeStart) > td.conf.duration() {
+		td.spikeTooLong = true
+	}
+}
+
+func (td *tapDetector) detected(start time.Time, peak r3.Vector) {
+	event := tapEvent{kind: tapEventSingle, time: start, axis: "x", peak: peak.Norm() / gravity}
+	component := peak.X
+	if largest := peak.Abs().LargestComponent(); largest == r3.YAxis {
+		component, event.axis = peak.Y, "y"
+	} else if largest == r3.ZAxis {
+		component, event.axis = peak.Z, "z"
+	}
+	event.direction = "positive"
+	if math.Signbit(component) {
+		event.direction = "negative"
+	}
+
+	td.taps++
+	td.push(event)
+	if td.pendingDouble && !td.conf.DisableDouble && start.Sub(td.lastTap) <= td.conf.window() {
+		event.kind = tapEventDouble
+		td.doubleTaps++
+		td.push(event)
+		td.pendingDouble = false
+	} else {
+		td.pendingDouble = true
+	}
+	td.lastTap = start
+}
+
+func (td *tapDetector) push(event tapEvent) {
+	if len(td.queue) >= td.conf.queueLength() {
+		td.queue = td.queue[1:]
+		td.dropped++
+	}
+	td.queue = append(td.queue, event)
+}
+
+// drain returns every queued event, oldest first, and empties the queue.
+func (td *tapDetector) drain() []tapEvent {
+	events := td.queue
+	td.queue = nil
+	return events
+}
@@ -0,0 +1,111 @@
+package mpu6050
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// tapSpike is extra acceleration starting at an offset and lasting a number of milliseconds.
+type tapSpike struct {
+	start  time.Duration
+	length int
+	accel  r3.Vector
+}
+
+// runTapSequence feeds a tap detector a sensor at rest, with spikes added, at 1kHz.
+func runTapSequence(td *tapDetector, total time.Duration, spikes []tapSpike) time.Time {
+	start := time.Now()
+	rest := r3.Vector{Z: gravity}
+	for elapsed := time.Duration(0); elapsed < total; elapsed += time.Millisecond {
+		accel := rest
+		for _, spike := range spikes {
+			if elapsed >= spike.start && elapsed < spike.start+time.Duration(spike.length)*time.Millisecond {
+				accel = rest.Add(spike.accel)
+			}
+		}
+		td.update(start.Add(elapsed), accel, 0.001)
+	}
+	return start
+}
+
+func TestSingleTap(t *testing.T) {
+	td := newTapDetector(Tap{})
+	start := runTapSequence(td, time.Second, []tapSpike{
+		{start: 500 * time.Millisecond, length: 10, accel: r3.Vector{X: -3 * gravity}},
+	})
+
+	events := td.drain()
+	test.That(t, len(events), test.ShouldEqual, 1)
+	test.That(t, events[0].kind, test.ShouldEqual, tapEventSingle)
+	test.That(t, events[0].axis, test.ShouldEqual, "x")
+	test.That(t, events[0].direction, test.ShouldEqual, "negative")
+	test.That(t, events[0].peak, test.ShouldAlmostEqual, 3, 0.1)
+	test.That(t, events[0].time, test.ShouldEqual, start.Add(500*time.Millisecond))
+	test.That(t, td.drain(), test.ShouldBeEmpty)
+}
+
+func TestDoubleTap(t *testing.T) {
+	td := newTapDetector(Tap{})
+	runTapSequence(td, 2*time.Second, []tapSpike{
+		{start: 500 * time.Millisecond, length: 10, accel: r3.Vector{Z: 2 * gravity}},
+		// Ringing during the latency is ignored.
+		{start: 550 * time.Millisecond, length: 5, accel: r3.Vector{Z: 2 * gravity}},
+		{start: 700 * time.Millisecond, length: 10, accel: r3.Vector{Z: 2 * gravity}},
+		// A third tap starts over.
+		{start: 1500 * time.Millisecond, length: 10, accel: r3.Vector{Y: 2 * gravity}},
+	})
+
+	events := td.drain()
+	kinds := []string{}
+	for _, event := range events {
+		kinds = append(kinds, event.kind)
+	}
+	test.That(t, kinds, test.ShouldResemble, []string{tapEventSingle, tapEventSingle, tapEventDouble, tapEventSingle})
+	test.That(t, events[2].axis, test.ShouldEqual, "z")
+	test.That(t, events[2].direction, test.ShouldEqual, "positive")
+	test.That(t, events[3].axis, test.ShouldEqual, "y")
+	test.That(t, td.taps, test.ShouldEqual, 3)
+	test.That(t, td.doubleTaps, test.ShouldEqual, 1)
+}
+
+func TestTapRejections(t *testing.T) {
+	td := newTapDetector(Tap{})
+	runTapSequence(td, 2*time.Second, []tapSpike{
+		// Too weak.
+		{start: 200 * time.Millisecond, length: 10, accel: r3.Vector{X: gravity}},
+		// Too long: that's a shove, not a tap.
+		{start: 800 * time.Millisecond, length: 200, accel: r3.Vector{X: 3 * gravity}},
+	})
+	test.That(t, td.drain(), test.ShouldBeEmpty)
+
+	// Taps too far apart aren't a double tap.
+	td = newTapDetector(Tap{})
+	runTapSequence(td, 2*time.Second, []tapSpike{
+		{start: 200 * time.Millisecond, length: 10, accel: r3.Vector{X: 3 * gravity}},
+		{start: 800 * time.Millisecond, length: 10, accel: r3.Vector{X: 3 * gravity}},
+	})
+	test.That(t, len(td.drain()), test.ShouldEqual, 2)
+	test.That(t, td.doubleTaps, test.ShouldEqual, 0)
+}
+
+func TestTapQueue(t *testing.T) {
+	td := newTapDetector(Tap{QueueLength: 2, DisableDouble: true})
+	runTapSequence(td, 2*time.Second, []tapSpike{
+		{start: 200 * time.Millisecond, length: 10, accel: r3.Vector{X: 3 * gravity}},
+		{start: 400 * time.Millisecond, length: 10, accel: r3.Vector{X: 3 * gravity}},
+		{start: 600 * time.Millisecond, length: 10, accel: r3.Vector{X: 3 * gravity}},
+	})
+	events := td.drain()
+	test.That(t, len(events), test.ShouldEqual, 2)
+	test.That(t, td.dropped, test.ShouldEqual, 1)
+	for _, event := range events {
+		test.That(t, event.kind, test.ShouldEqual, tapEventSingle)
+	}
+
+	test.That(t, (&Tap{}).validate(), test.ShouldBeNil)
+	test.That(t, (&Tap{ThresholdG: -1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Tap{LatencyMs: 500, WindowMs: 400}).validate(), test.ShouldNotBeNil)
+}
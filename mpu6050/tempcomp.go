@@ -0,0 +1,111 @@
+package mpu6050
+
+import (
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+)
+
+// maxTemperatureDegree is the highest polynomial degree we allow for the temperature model. Higher
+// degrees fit the noise in a sweep rather than the chip's behavior.
+const maxTemperatureDegree = 3
+
+// TemperatureCompensation models the gyroscope bias as a polynomial in the die temperature, and is
+// subtracted from every sample on top of any fixed calibration offsets.
+type TemperatureCompensation struct {
+	// ReferenceCelsius is the temperature the polynomials are centered on.
+	ReferenceCelsius float64 `json:"reference_celsius"`
+	// GyroCoefficients holds one polynomial for each of the X, Y, and Z axes. Coefficient i
+	// multiplies (temperature - reference)^i, and the result is a bias in degrees per second.
+	GyroCoefficients [][]float64 `json:"gyro_coefficients_dps"`
+}
+
+// TemperatureSample is one stationary gyroscope reading, used to fit a TemperatureCompensation.
+type TemperatureSample struct {
+	Celsius float64
+	// Gyro is the angular velocity in degrees per second, in X, Y, Z order.
+	Gyro [3]float64
+}
+
+func (tc *TemperatureCompensation) validate() error {
+	if len(tc.GyroCoefficients) != 3 {
+		return errors.Errorf("temperature_compensation.gyro_coefficients_dps must have 3 polynomials, got %d",
+			len(tc.GyroCoefficients))
+	}
+	for _, coefficients := range tc.GyroCoefficients {
+		if len(coefficients) == 0 || len(coefficients) > maxTemperatureDegree+1 {
+			return errors.Errorf("temperature_compensation polynomials must have between 1 and %d coefficients",
+				maxTemperatureDegree+1)
+		}
+	}
+	return nil
+}
+
+// gyroBias returns the modeled gyroscope bias at the given temperature, in degrees per second.
+func (tc *TemperatureCompensation) gyroBias(celsius float64) [3]float64 {
+	var bias [3]float64
+	for axis, coefficients := range tc.GyroCoefficients {
+		bias[axis] = evaluatePolynomial(coefficients, celsius-tc.ReferenceCelsius)
+	}
+	return bias
+}
+
+func evaluatePolynomial(coefficients []float64, x float64) float64 {
+	// Horner's method, from the highest-order coefficient down.
+	result := 0.0
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = result*x + coefficients[i]
+	}
+	return result
+}
+
+// FitTemperatureCompensation fits a polynomial of the given degree to the gyroscope bias on each
+// axis, from samples recorded while the chip sat still through a range of temperatures. The
+// polynomials are centered on the mean temperature of the samples.
+func FitTemperatureCompensation(samples []TemperatureSample, degree int) (*TemperatureCompensation, error) {
+	if degree < 0 || degree > maxTemperatureDegree {
+		return nil, errors.Errorf("degree must be between 0 and %d", maxTemperatureDegree)
+	}
+	if len(samples) <= degree {
+		return nil, errors.Errorf("need more than %d samples to fit a degree %d polynomial", degree, degree)
+	}
+
+	reference := 0.0
+	minTemp, maxTemp := samples[0].Celsius, samples[0].Celsius
+	for _, sample := range samples {
+		reference += sample.Celsius
+		minTemp = min(minTemp, sample.Celsius)
+		maxTemp = max(maxTemp, sample.Celsius)
+	}
+	reference /= float64(len(samples))
+	if degree > 0 && maxTemp-minTemp < 1 {
+		return nil, errors.Errorf("the samples only cover %.2f degrees C, which is not enough of a sweep to fit", maxTemp-minTemp)
+	}
+
+	// Least squares on the Vandermonde matrix of the centered temperatures.
+	vandermonde := mat.NewDense(len(samples), degree+1, nil)
+	biases := mat.NewDense(len(samples), 3, nil)
+	for row, sample := range samples {
+		power := 1.0
+		for col := 0; col <= degree; col++ {
+			vandermonde.Set(row, col, power)
+			power *= sample.Celsius - reference
+		}
+		for axis := range 3 {
+			biases.Set(row, axis, sample.Gyro[axis])
+		}
+	}
+	var solution mat.Dense
+	if err := solution.Solve(vandermonde, biases); err != nil {
+		return nil, errors.Wrap(err, "unable to fit temperature model")
+	}
+
+	result := &TemperatureCompensation{ReferenceCelsius: reference}
+	for axis := range 3 {
+		coefficients := make([]float64, degree+1)
+		for i := range coefficients {
+			coefficients[i] = solution.At(i, axis)
+		}
+		result.GyroCoefficients = append(result.GyroCoefficients, coefficients)
+	}
+	return result, nil
+}
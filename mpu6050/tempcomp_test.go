@@ -0,0 +1,69 @@
+package mpu6050
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestFitTemperatureCompensation(t *testing.T) {
+	// Biases that follow known quadratics in temperature.
+	trueBias := func(celsius float64) [3]float64 {
+		return [3]float64{
+			0.5 + 0.1*(celsius-30),
+			-1 + 0.02*(celsius-30)*(celsius-30),
+			2,
+		}
+	}
+	var samples []TemperatureSample
+	for celsius := 10.0; celsius <= 50; celsius += 0.5 {
+		samples = append(samples, TemperatureSample{Celsius: celsius, Gyro: trueBias(celsius)})
+	}
+
+	compensation, err := FitTemperatureCompensation(samples, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, compensation.validate(), test.ShouldBeNil)
+	test.That(t, compensation.ReferenceCelsius, test.ShouldAlmostEqual, 30.0)
+	for _, celsius := range []float64{12, 25.3, 48} {
+		expected := trueBias(celsius)
+		actual := compensation.gyroBias(celsius)
+		for axis := range 3 {
+			test.That(t, actual[axis], test.ShouldAlmostEqual, expected[axis], 1e-9)
+		}
+	}
+
+	_, err = FitTemperatureCompensation(samples[:2], 2)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = FitTemperatureCompensation(samples, 5)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// A sweep that barely changes temperature can't be fit.
+	flat := []TemperatureSample{{Celsius: 30}, {Celsius: 30.1}, {Celsius: 30.2}, {Celsius: 30.3}}
+	_, err = FitTemperatureCompensation(flat, 1)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestTemperatureCompensationValidation(t *testing.T) {
+	tc := &TemperatureCompensation{GyroCoefficients: [][]float64{{1}, {2}}}
+	test.That(t, tc.validate(), test.ShouldNotBeNil)
+	tc = &TemperatureCompensation{GyroCoefficients: [][]float64{{1}, {}, {3}}}
+	test.That(t, tc.validate(), test.ShouldNotBeNil)
+	tc = &TemperatureCompensation{GyroCoefficients: [][]float64{{1}, {2}, {1, 2, 3, 4, 5}}}
+	test.That(t, tc.validate(), test.ShouldNotBeNil)
+	tc = &TemperatureCompensation{ReferenceCelsius: 20, GyroCoefficients: [][]float64{{1}, {2, 1}, {3}}}
+	test.That(t, tc.validate(), test.ShouldBeNil)
+	test.That(t, tc.gyroBias(22), test.ShouldResemble, [3]float64{1, 4, 3})
+}
+
+func TestTemperatureCompensationReplacesGyroBias(t *testing.T) {
+	tc := &TemperatureCompensation{GyroCoefficients: [][]float64{{1}, {2}, {3}}}
+	cfg := Config{I2cBus: i2cName, TemperatureCompensation: tc, Calibration: &Calibration{AccelBias: []float64{0, 0, 0.1}}}
+	_, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+
+	// The model's constant term is the bias, so a calibrated bias on top of it is refused.
+	cfg.Calibration.GyroBias = []float64{1, 2, 3}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "gyro_bias_dps")
+}
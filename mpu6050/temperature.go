@@ -0,0 +1,261 @@
+package mpu6050
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+// TemperatureModel is a sensor that reports the temperature of an MPU-6050's die.
+var TemperatureModel = resource.NewModel("viam", "tdk-invensense", "mpu6050-temperature")
+
+// TemperatureConfig configures the temperature sensor. The bus attributes mean the same as in
+// Config, and should match those of the movement sensor on the same chip, if there is one.
+type TemperatureConfig struct {
+	Board                  string `json:"board,omitempty"`
+	I2cBus                 string `json:"i2c_bus,omitempty"`
+	UseAlternateI2CAddress bool   `json:"use_alt_i2c_address,omitempty"`
+	I2cAddress             int    `json:"i2c_address,omitempty"`
+	Mux                    *Mux   `json:"mux,omitempty"`
+	SPI                    *SPI   `json:"spi,omitempty"`
+	// ChipHealth adds readings about how well the chip is being sampled.
+	ChipHealth bool `json:"chip_health,omitempty"`
+}
+
+// chipConfig returns a movement sensor config for the same chip, with every feature off.
+func (conf *TemperatureConfig) chipConfig() *Config {
+	return &Config{
+		Board:                  conf.Board,
+		I2cBus:                 conf.I2cBus,
+		UseAlternateI2CAddress: conf.UseAlternateI2CAddress,
+		I2cAddress:             conf.I2cAddress,
+		Mux:                    conf.Mux,
+		SPI:                    conf.SPI,
+	}
+}
+
+// Validate ensures all parts of the config are valid, and then returns the list of things we
+// depend on.
+func (conf *TemperatureConfig) Validate(path string) ([]string, error) {
+	return conf.chipConfig().Validate(path)
+}
+
+// chipUsers records the components using one chip.
+type chipUsers struct {
+	movementSensor     *mpu6050
+	temperatureSensors int
+}
+
+// chips holds the components using each chip, keyed by chipKey.
+var chips = struct {
+	mu     sync.Mutex
+	byChip map[string]*chipUsers
+}{byChip: map[string]*chipUsers{}}
+
+// chipKey identifies the chip at tr. The bus key tells apart buses on different boards, as
+// described at i2cBusKey.
+func chipKey(tr transport) string {
+	return tr.busKey() + " " + tr.String()
+}
+
+func chipUsersLocked(key string) *chipUsers {
+	users, ok := chips.byChip[key]
+	if !ok {
+		users = &chipUsers{}
+		chips.byChip[key] = users
+	}
+	return users
+}
+
+func releaseChipLocked(key string, users *chipUsers) {
+	if users.movementSensor == nil && users.temperatureSensors == 0 {
+		delete(chips.byChip, key)
+	}
+}
+
+// attachMovementSensor lets temperature sensors on the same chip use mpu's samples.
+func attachMovementSensor(key string, mpu *mpu6050) {
+	chips.mu.Lock()
+	defer chips.mu.Unlock()
+	chipUsersLocked(key).movementSensor = mpu
+}
+
+// detachMovementSensor stops temperature sensors from using mpu's samples, and returns whether
+// any of them still use the chip.
+func detachMovementSensor(key string, mpu *mpu6050) bool {
+	chips.mu.Lock()
+	defer chips.mu.Unlock()
+	users, ok := chips.byChip[key]
+	if !ok {
+		return false
+	}
+	if users.movementSensor == mpu {
+		users.movementSensor = nil
+	}
+	releaseChipLocked(key, users)
+	return users.temperatureSensors > 0
+}
+
+// attachTemperatureSensor records a temperature sensor on the chip, and returns the movement
+// sensor already sampling it, if there is one.
+func attachTemperatureSensor(key string) *mpu6050 {
+	chips.mu.Lock()
+	defer chips.mu.Unlock()
+	users := chipUsersLocked(key)
+	users.temperatureSensors++
+	return users.movementSensor
+}
+
+// detachTemperatureSensor removes a temperature sensor from the chip, and returns whether anything
+// else still uses it.
+func detachTemperatureSensor(key string) bool {
+	chips.mu.Lock()
+	defer chips.mu.Unlock()
+	users, ok := chips.byChip[key]
+	if !ok {
+		return false
+	}
+	users.temperatureSensors--
+	releaseChipLocked(key, users)
+	return users.movementSensor != nil || users.temperatureSensors > 0
+}
+
+// movementSensorOn returns the movement sensor sampling the chip, or nil if there isn't one.
+func movementSensorOn(key string) *mpu6050 {
+	chips.mu.Lock()
+	defer chips.mu.Unlock()
+	if users, ok := chips.byChip[key]; ok {
+		return users.movementSensor
+	}
+	return nil
+}
+
+// temperatureSensor reports the chip's temperature. While a movement sensor is sampling the same
+// chip, it reports the temperature from that sensor's latest sample without touching the bus;
+// otherwise, it reads the temperature off the chip itself.
+type temperatureSensor struct {
+	resource.Named
+	resource.AlwaysRebuild
+	transport  transport
+	chipKey    string
+	chipHealth bool
+	// model is the chip, which sets how its temperature is converted.
+	model  chipModel
+	logger logging.Logger
+}
+
+func newTemperatureSensor(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger logging.Logger,
+) (sensor.Sensor, error) {
+	newConf, err := resource.NativeConfig[*TemperatureConfig](conf)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := newConf.chipConfig().newTransport(deps, logger)
+	if err != nil {
+		return nil, err
+	}
+	return makeTemperatureSensor(ctx, logger, conf.ResourceName(), newConf, tr)
+}
+
+// makeTemperatureSensor is separated from newTemperatureSensor so you can inject a mock bus in
+// tests.
+func makeTemperatureSensor(
+	ctx context.Context,
+	logger logging.Logger,
+	name resource.Name,
+	conf *TemperatureConfig,
+	tr transport,
+) (sensor.Sensor, error) {
+	ts := &temperatureSensor{
+		Named:      name.AsNamed(),
+		transport:  tr,
+		chipKey:    chipKey(tr),
+		chipHealth: conf.ChipHealth,
+		logger:     logger,
+	}
+	if mpu := attachTemperatureSensor(ts.chipKey); mpu != nil {
+		// The movement sensor has already set up the chip.
+		ts.model = mpu.model
+		return ts, nil
+	}
+
+	if err := ts.wake(ctx); err != nil {
+		detachTemperatureSensor(ts.chipKey)
+		return nil, err
+	}
+	return ts, nil
+}
+
+// wake checks that the chip is there, and takes it out of sleep mode so its thermometer runs.
+func (ts *temperatureSensor) wake(ctx context.Context) error {
+	result, err := ts.transport.readBlock(ctx, defaultAddressRegister, 1)
+	if err != nil {
+		return readError(err, ts.transport)
+	}
+	model, ok := chipModels[result[0]]
+	if !ok {
+		return unexpectedDeviceError(ts.transport, result[0])
+	}
+	ts.model = model
+	return errors.Wrap(ts.transport.writeByte(ctx, powerRegister, 0), "unable to wake up MPU6050")
+}
+
+func (ts *temperatureSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	if mpu := movementSensorOn(ts.chipKey); mpu != nil {
+		return mpu.temperatureReadings(ts.chipHealth)
+	}
+
+	data, err := ts.transport.readBlock(ctx, temperatureRegister, 2)
+	if err != nil {
+		return nil, readError(err, ts.transport)
+	}
+	readings := map[string]interface{}{"temperature_celsius": ts.model.temperature(data[0:2])}
+	if ts.chipHealth {
+		readings["shared_with_movement_sensor"] = false
+	}
+	return readings, nil
+}
+
+func (ts *temperatureSensor) Close(ctx context.Context) error {
+	if detachTemperatureSensor(ts.chipKey) {
+		return nil
+	}
+	// Nothing else uses the chip, so put it back to sleep.
+	err := ts.transport.writeByte(ctx, powerRegister, 1<<6)
+	if err != nil {
+		ts.logger.CError(ctx, err)
+	}
+	return err
+}
+
+// temperatureReadings returns the readings for a temperature sensor on this chip.
+func (mpu *mpu6050) temperatureReadings(chipHealth bool) (map[string]interface{}, error) {
+	mpu.mu.Lock()
+	defer mpu.mu.Unlock()
+
+	readings := map[string]interface{}{"temperature_celsius": mpu.temperature}
+	if chipHealth {
+		readings["shared_with_movement_sensor"] = true
+		readings["bus_utilization"] = mpu.busUtilization
+		if !mpu.sampledAt.IsZero() {
+			readings["last_sample_age_sec"] = time.Since(mpu.sampledAt).Seconds()
+		}
+		if mpu.vibration != nil {
+			readings["fifo_overflows"] = mpu.fifoOverflows
+		}
+		for key, value := range mpu.errorReport.readings() {
+			readings[key] = value
+		}
+	}
+	return readings, mpu.err.Get()
+}
@@ -0,0 +1,112 @@
+package mpu6050
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestTemperatureSensor(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// Read on its own, TEMP_OUT comes first and is 0, which is 36.53 degrees. In a full sample, it
+	// comes after the accelerometer and is 18.3 degrees.
+	mockData := make([]byte, 16)
+	mockData[6] = 231
+	mockData[7] = 202
+	var sleeps atomic.Int32
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		if register == defaultAddressRegister {
+			return []byte{expectedDefaultAddress}, nil
+		}
+		return mockData, nil
+	}
+	i2cHandle.WriteByteDataFunc = func(ctx context.Context, register, data byte) error {
+		if register == powerRegister && data == 1<<6 {
+			sleeps.Add(1)
+		}
+		return nil
+	}
+	i2cHandle.CloseFunc = func() error { return nil }
+	bus := &inject.I2C{}
+	bus.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	conf := &TemperatureConfig{I2cBus: i2cName, ChipHealth: true}
+	_, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	tr := newI2CTransport(bus, "", i2cName, expectedDefaultAddress, logger)
+	ts, err := makeTemperatureSensor(ctx, logger, sensor.Named("temp"), conf, tr)
+	test.That(t, err, test.ShouldBeNil)
+
+	readings, err := ts.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["temperature_celsius"], test.ShouldAlmostEqual, 36.53)
+	test.That(t, readings["shared_with_movement_sensor"], test.ShouldBeFalse)
+
+	// Once a movement sensor samples the same chip, the temperature comes from its samples.
+	mpu, err := makeMpu6050(ctx, logger, testName, &Config{I2cBus: i2cName}, bus)
+	test.That(t, err, test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		readings, err := ts.Readings(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, readings["shared_with_movement_sensor"], test.ShouldBeTrue)
+		test.That(tb, readings["temperature_celsius"], test.ShouldAlmostEqual, 18.3, 0.001)
+		test.That(tb, readings["last_sample_age_sec"], test.ShouldBeLessThan, 1)
+	})
+
+	// Closing the movement sensor leaves the chip awake for the temperature sensor.
+	test.That(t, mpu.Close(ctx), test.ShouldBeNil)
+	test.That(t, sleeps.Load(), test.ShouldEqual, 0)
+	readings, err = ts.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["temperature_celsius"], test.ShouldAlmostEqual, 36.53)
+
+	test.That(t, ts.Close(ctx), test.ShouldBeNil)
+	test.That(t, sleeps.Load(), test.ShouldEqual, 1)
+	chips.mu.Lock()
+	test.That(t, chips.byChip, test.ShouldNotContainKey, chipKey(tr))
+	chips.mu.Unlock()
+}
+
+func TestTemperatureByModel(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// The MPU-6500 family has a different temperature scale: TEMP_OUT of 0 is 21 degrees.
+	i2cHandle := &inject.I2CHandle{}
+	i2cHandle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+		if register == defaultAddressRegister {
+			return []byte{0x71}, nil
+		}
+		return make([]byte, numBytes), nil
+	}
+	i2cHandle.WriteByteDataFunc = func(ctx context.Context, register, data byte) error { return nil }
+	i2cHandle.CloseFunc = func() error { return nil }
+	bus := &inject.I2C{}
+	bus.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		return i2cHandle, nil
+	}
+
+	tr := newI2CTransport(bus, "", i2cName, expectedDefaultAddress, logger)
+	ts, err := makeTemperatureSensor(ctx, logger, sensor.Named("temp"), &TemperatureConfig{I2cBus: i2cName}, tr)
+	test.That(t, err, test.ShouldBeNil)
+	defer ts.Close(ctx)
+	readings, err := ts.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["temperature_celsius"], test.ShouldAlmostEqual, 21)
+
+	test.That(t, mpu6050Model.temperature([]byte{0xF2, 0xB4}), test.ShouldAlmostEqual, 36.53-3404.0/340)
+	test.That(t, chipModels[0x73].temperature([]byte{0x0D, 0x0B}), test.ShouldAlmostEqual, 21+3339.0/333.87)
+	test.That(t, chipModels[0x73].name, test.ShouldEqual, "MPU-9255")
+}
@@ -0,0 +1,79 @@
+package mpu6050
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/utils"
+)
+
+const defaultTiltTimeConstant = 0.5 // seconds
+
+// Tilt configures the inclinometer outputs, which measure pitch and roll from the direction of
+// gravity alone. They don't need the orientation filter, and they are only accurate while the
+// sensor isn't otherwise accelerating; the stable flag says when that is.
+type Tilt struct {
+	// TimeConstantSec sets how heavily the acceleration is smoothed before the angles are computed.
+	// Longer time constants reject more vibration but respond more slowly.
+	TimeConstantSec float64 `json:"time_constant_sec,omitempty"`
+}
+
+func (t *Tilt) validate() error {
+	if t.TimeConstantSec < 0 {
+		return errors.Errorf("tilt.time_constant_sec must not be negative, got %g", t.TimeConstantSec)
+	}
+	return nil
+}
+
+func (t *Tilt) timeConstant() float64 {
+	if t.TimeConstantSec == 0 {
+		return defaultTiltTimeConstant
+	}
+	return t.TimeConstantSec
+}
+
+// tiltEstimator low-pass filters the acceleration to find gravity.
+type tiltEstimator struct {
+	timeConstant float64
+	// smoothed is the filtered acceleration in m/sec/sec.
+	smoothed    r3.Vector
+	initialized bool
+}
+
+func newTiltEstimator(conf Tilt) *tiltEstimator {
+	return &tiltEstimator{timeConstant: conf.timeConstant()}
+}
+
+// update adds a sample of acceleration in m/sec/sec, dt seconds after the last one.
+func (te *tiltEstimator) update(accel r3.Vector, dt float64) {
+	if !te.initialized {
+		te.smoothed = accel
+		te.initialized = true
+		return
+	}
+	alpha := dt / (te.timeConstant + dt)
+	te.smoothed = te.smoothed.Add(accel.Sub(te.smoothed).Mul(alpha))
+}
+
+// tiltAngles are the inclinometer outputs, in degrees.
+type tiltAngles struct {
+	pitch, roll float64
+	// inclination is the angle between the sensor's Z axis and vertical.
+	inclination float64
+}
+
+// angles returns the pitch and roll that would bring the sensor level, matching the conventions of
+// the orientation filter, and the total inclination from vertical.
+func (te *tiltEstimator) angles() tiltAngles {
+	a := te.smoothed
+	norm := a.Norm()
+	if norm == 0 {
+		return tiltAngles{}
+	}
+	return tiltAngles{
+		roll:        utils.RadToDeg(math.Atan2(a.Y, a.Z)),
+		pitch:       utils.RadToDeg(math.Atan2(-a.X, math.Hypot(a.Y, a.Z))),
+		inclination: utils.RadToDeg(math.Acos(math.Max(-1, math.Min(1, a.Z/norm)))),
+	}
+}
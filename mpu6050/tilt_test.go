@@ -0,0 +1,55 @@
+package mpu6050
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/test"
+)
+
+func TestTiltAngles(t *testing.T) {
+	te := newTiltEstimator(Tilt{})
+	test.That(t, te.timeConstant, test.ShouldEqual, defaultTiltTimeConstant)
+
+	// Rolled 30 degrees, then pitched 20 degrees: the same convention as levelQuaternion.
+	roll, pitch := utils.DegToRad(30), utils.DegToRad(20)
+	accel := r3.Vector{
+		X: -gravity * math.Sin(pitch),
+		Y: gravity * math.Cos(pitch) * math.Sin(roll),
+		Z: gravity * math.Cos(pitch) * math.Cos(roll),
+	}
+	te.update(accel, 0)
+	angles := te.angles()
+	test.That(t, angles.roll, test.ShouldAlmostEqual, 30)
+	test.That(t, angles.pitch, test.ShouldAlmostEqual, 20)
+	// The Z axis is tilted away from vertical by both.
+	test.That(t, angles.inclination, test.ShouldAlmostEqual, utils.RadToDeg(math.Acos(math.Cos(pitch)*math.Cos(roll))))
+
+	upsideDown := newTiltEstimator(Tilt{})
+	upsideDown.update(r3.Vector{Z: -gravity}, 0)
+	test.That(t, upsideDown.angles().inclination, test.ShouldAlmostEqual, 180)
+}
+
+func TestTiltSmoothing(t *testing.T) {
+	te := newTiltEstimator(Tilt{TimeConstantSec: 1})
+	te.update(r3.Vector{Z: gravity}, 0)
+
+	// Vibration along X averages out.
+	dt := 0.001
+	for i := range 1000 {
+		te.update(r3.Vector{X: 5 * math.Sin(2*math.Pi*50*float64(i)*dt), Z: gravity}, dt)
+	}
+	test.That(t, math.Abs(te.angles().pitch), test.ShouldBeLessThan, 0.5)
+
+	// A real change in tilt comes through after a few time constants.
+	level := r3.Vector{Y: gravity}
+	for range 5000 {
+		te.update(level, dt)
+	}
+	test.That(t, te.angles().roll, test.ShouldAlmostEqual, 90, 1)
+	test.That(t, te.angles().inclination, test.ShouldAlmostEqual, 90, 1)
+
+	test.That(t, (&Tilt{TimeConstantSec: -1}).validate(), test.ShouldNotBeNil)
+}
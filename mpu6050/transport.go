@@ -0,0 +1,282 @@
+package mpu6050
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+const (
+	// Setting the top bit of the register address makes an SPI transfer a read.
+	spiReadBit = 0x80
+	// The MPU-6000 runs in SPI mode 3: the clock idles high and data is sampled on its rising edge.
+	spiMode = 3
+	// Setting I2C_IF_DIS in USER_CTRL keeps an SPI chip from falling back to I2C.
+	userControlI2CDisable = 1 << 4
+	// The sensor registers readable on the fast clock run from INT_STATUS through EXT_SENS_DATA_23.
+	lastSensorRegister = 96
+)
+
+// transport reads and writes the chip's registers over the bus it is wired to.
+type transport interface {
+	readBlock(ctx context.Context, register byte, length uint8) ([]byte, error)
+	writeByte(ctx context.Context, register, value byte) error
+	// userControl returns the USER_CTRL bits that must stay set for this bus.
+	userControl() byte
+	// busKey identifies the bus, so that every chip on it can share a scheduler.
+	busKey() string
+	// String describes where the chip is, for error messages.
+	String() string
+}
+
+// newTransport connects to the bus selected by the config. deps are only needed if the config
+// names a board.
+func (conf *Config) newTransport(deps resource.Dependencies, logger logging.Logger) (transport, error) {
+	if conf.SPI != nil {
+		bus, err := openSPIBus(conf.SPI.Bus)
+		if err != nil {
+			return nil, err
+		}
+		return newSPITransport(bus, *conf.SPI, logger), nil
+	}
+	bus, err := conf.i2cBus(deps)
+	if err != nil {
+		return nil, err
+	}
+	tr := newI2CTransport(bus, conf.Board, conf.I2cBus, conf.address(), logger)
+	if conf.Mux != nil {
+		tr.mux = &i2cMux{address: byte(conf.Mux.address()), channel: byte(conf.Mux.Channel)}
+	}
+	return tr, nil
+}
+
+// i2cBus gets the I2C bus from the board if the config names one, and opens it directly otherwise.
+func (conf *Config) i2cBus(deps resource.Dependencies) (buses.I2C, error) {
+	if conf.Board == "" {
+		return openI2CBus(conf.I2cBus)
+	}
+	b, err := board.FromDependencies(deps, conf.Board)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := b.(I2CProvider)
+	if !ok {
+		return nil, errors.Errorf("board %q can't supply I2C buses: it has no I2CByName method", conf.Board)
+	}
+	bus, err := provider.I2CByName(conf.I2cBus)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get I2C bus %s from board %q", conf.I2cBus, conf.Board)
+	}
+	return bus, nil
+}
+
+type i2cTransport struct {
+	bus buses.I2C
+	// board is the board that supplies the bus, or empty if it is opened directly.
+	board   string
+	busName string
+	address byte
+	// mux is the multiplexer channel the chip sits behind, or nil if it is directly on the bus.
+	mux *i2cMux
+	// mu is shared by every transport on the bus. While a multiplexer channel is selected, the chips
+	// on it answer alongside the ones wired directly to the bus and those behind other
+	// multiplexers, so nobody else may talk until it is deselected.
+	mu     *sync.Mutex
+	logger logging.Logger
+}
+
+func newI2CTransport(bus buses.I2C, board, busName string, address byte, logger logging.Logger) *i2cTransport {
+	return &i2cTransport{
+		bus:     bus,
+		board:   board,
+		busName: busName,
+		address: address,
+		mu:      i2cBusLock(board, busName),
+		logger:  logger,
+	}
+}
+
+// i2cMux is a channel of a TCA9548A multiplexer.
+type i2cMux struct {
+	address byte
+	channel byte
+}
+
+// i2cBusKey identifies the I2C bus named busName on board. Boards can have buses with the same
+// names, so the board is part of it.
+func i2cBusKey(board, busName string) string {
+	return board + "/" + busName
+}
+
+// i2cBusLocks holds the lock for each I2C bus, keyed by i2cBusKey.
+var i2cBusLocks = struct {
+	mu    sync.Mutex
+	byBus map[string]*sync.Mutex
+}{byBus: map[string]*sync.Mutex{}}
+
+func i2cBusLock(board, busName string) *sync.Mutex {
+	i2cBusLocks.mu.Lock()
+	defer i2cBusLocks.mu.Unlock()
+	key := i2cBusKey(board, busName)
+	lock, ok := i2cBusLocks.byBus[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		i2cBusLocks.byBus[key] = lock
+	}
+	return lock
+}
+
+// withHandle selects the multiplexer channel, if there is one, and runs fn with a handle to the
+// chip. The bus stays locked until fn returns and the channel is deselected again.
+func (t *i2cTransport) withHandle(ctx context.Context, fn func(buses.I2CHandle) error) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.mux != nil {
+		if err := t.selectChannels(ctx, 1<<t.mux.channel); err != nil {
+			return err
+		}
+		defer func() {
+			if deselectErr := t.selectChannels(ctx, 0); err == nil {
+				err = deselectErr
+			}
+		}()
+	}
+
+	handle, err := t.bus.OpenHandle(t.address)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := handle.Close()
+		if err != nil {
+			t.logger.CError(ctx, err)
+		}
+	}()
+	return fn(handle)
+}
+
+// selectChannels connects the multiplexer's channels in mask to the bus, and disconnects the rest.
+// The TCA9548A has a single control register, with one bit per channel, which is written without a
+// register address.
+func (t *i2cTransport) selectChannels(ctx context.Context, mask byte) error {
+	handle, err := t.bus.OpenHandle(t.mux.address)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := handle.Close()
+		if err != nil {
+			t.logger.CError(ctx, err)
+		}
+	}()
+	return errors.Wrapf(handle.Write(ctx, []byte{mask}),
+		"can't select channels %#08b on I2C multiplexer %d", mask, t.mux.address)
+}
+
+func (t *i2cTransport) readBlock(ctx context.Context, register byte, length uint8) ([]byte, error) {
+	var results []byte
+	err := t.withHandle(ctx, func(handle buses.I2CHandle) error {
+		var err error
+		results, err = handle.ReadBlockData(ctx, register, length)
+		return err
+	})
+	return results, err
+}
+
+func (t *i2cTransport) writeByte(ctx context.Context, register, value byte) error {
+	return t.withHandle(ctx, func(handle buses.I2CHandle) error {
+		return handle.WriteByteData(ctx, register, value)
+	})
+}
+
+func (t *i2cTransport) userControl() byte {
+	return 0
+}
+
+func (t *i2cTransport) busKey() string {
+	return "i2c:" + i2cBusKey(t.board, t.busName)
+}
+
+func (t *i2cTransport) String() string {
+	if t.mux != nil {
+		return fmt.Sprintf("I2C address %d on bus %s, multiplexer %d channel %d",
+			t.address, t.busName, t.mux.address, t.mux.channel)
+	}
+	return fmt.Sprintf("I2C address %d on bus %s", t.address, t.busName)
+}
+
+// spiTransport talks to the chip over SPI. Every register can be accessed at up to 1MHz, but the
+// sensor data, interrupt status, and FIFO can be read at up to 20MHz, so those reads switch to the
+// faster clock.
+type spiTransport struct {
+	bus    buses.SPI
+	conf   SPI
+	logger logging.Logger
+}
+
+func newSPITransport(bus buses.SPI, conf SPI, logger logging.Logger) *spiTransport {
+	return &spiTransport{bus: bus, conf: conf, logger: logger}
+}
+
+// isSensorRegister returns whether a read starting at register can use the fast clock.
+func isSensorRegister(register byte) bool {
+	return (register >= intStatusRegister && register <= lastSensorRegister) ||
+		(register >= fifoCountRegister && register <= fifoDataRegister)
+}
+
+func (t *spiTransport) transfer(ctx context.Context, speed int, tx []byte) ([]byte, error) {
+	handle, err := t.bus.OpenHandle()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := handle.Close()
+		if err != nil {
+			t.logger.CError(ctx, err)
+		}
+	}()
+
+	return handle.Xfer(ctx, uint(speed), t.conf.ChipSelect, spiMode, tx)
+}
+
+func (t *spiTransport) readBlock(ctx context.Context, register byte, length uint8) ([]byte, error) {
+	speed := t.conf.registerSpeed()
+	if isSensorRegister(register) {
+		speed = t.conf.sensorSpeed()
+	}
+	// The chip sends the registers back, one after another, while we clock out zeros after the
+	// address.
+	tx := make([]byte, 1+int(length))
+	tx[0] = register | spiReadBit
+	rx, err := t.transfer(ctx, speed, tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rx) != len(tx) {
+		return nil, errors.Errorf("short SPI read from %s: got %d bytes, wanted %d", t, len(rx), len(tx))
+	}
+	return rx[1:], nil
+}
+
+func (t *spiTransport) writeByte(ctx context.Context, register, value byte) error {
+	_, err := t.transfer(ctx, t.conf.registerSpeed(), []byte{register &^ spiReadBit, value})
+	return err
+}
+
+func (t *spiTransport) userControl() byte {
+	return userControlI2CDisable
+}
+
+func (t *spiTransport) busKey() string {
+	return "spi:" + t.conf.Bus
+}
+
+func (t *spiTransport) String() string {
+	return fmt.Sprintf("SPI bus %s chip select %s", t.conf.Bus, t.conf.ChipSelect)
+}
@@ -0,0 +1,229 @@
+package mpu6050
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/board/genericlinux/buses"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+// spiTransfer records one SPI transfer.
+type spiTransfer struct {
+	baud       uint
+	chipSelect string
+	mode       uint
+	tx         []byte
+}
+
+// fakeSPIHandle answers reads from a register file, and records every transfer.
+type fakeSPIHandle struct {
+	registers [128]byte
+	transfers []spiTransfer
+}
+
+func (h *fakeSPIHandle) Xfer(ctx context.Context, baud uint, chipSelect string, mode uint, tx []byte) ([]byte, error) {
+	h.transfers = append(h.transfers, spiTransfer{baud: baud, chipSelect: chipSelect, mode: mode, tx: tx})
+	rx := make([]byte, len(tx))
+	register := tx[0] &^ spiReadBit
+	if tx[0]&spiReadBit == 0 {
+		h.registers[register] = tx[1]
+		return rx, nil
+	}
+	copy(rx[1:], h.registers[register:])
+	return rx, nil
+}
+
+func (h *fakeSPIHandle) Close() error {
+	return nil
+}
+
+func setupSPI(handle *fakeSPIHandle) buses.SPI {
+	spi := &inject.SPI{}
+	spi.OpenHandleFunc = func() (buses.SPIHandle, error) {
+		return handle, nil
+	}
+	return spi
+}
+
+func TestSPITransport(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+	handle := &fakeSPIHandle{}
+	handle.registers[dataRegister] = 0x12
+	handle.registers[dataRegister+1] = 0x34
+	tr := newSPITransport(setupSPI(handle), SPI{Bus: "0", ChipSelect: "1"}, logger)
+
+	// Sensor reads set the read bit, clock out a byte per register, and use the fast clock.
+	data, err := tr.readBlock(ctx, dataRegister, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, data, test.ShouldResemble, []byte{0x12, 0x34})
+	test.That(t, handle.transfers[0], test.ShouldResemble, spiTransfer{
+		baud: defaultSPISensorSpeed, chipSelect: "1", mode: spiMode, tx: []byte{dataRegister | spiReadBit, 0, 0},
+	})
+
+	// Everything else uses the slow clock.
+	test.That(t, tr.writeByte(ctx, gyroConfigRegister, 0x18), test.ShouldBeNil)
+	test.That(t, handle.transfers[1].tx, test.ShouldResemble, []byte{gyroConfigRegister, 0x18})
+	test.That(t, handle.transfers[1].baud, test.ShouldEqual, defaultSPIRegisterSpeed)
+	value, err := tr.readBlock(ctx, gyroConfigRegister, 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, value, test.ShouldResemble, []byte{0x18})
+	test.That(t, handle.transfers[2].baud, test.ShouldEqual, defaultSPIRegisterSpeed)
+
+	// The FIFO is read on the fast clock too, at the configured speed.
+	tr = newSPITransport(setupSPI(handle), SPI{Bus: "0", ChipSelect: "1", SensorSpeedHz: 8000000}, logger)
+	_, err = tr.readBlock(ctx, fifoDataRegister, 12)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, handle.transfers[3].baud, test.ShouldEqual, 8000000)
+	test.That(t, tr.String(), test.ShouldEqual, "SPI bus 0 chip select 1")
+}
+
+func TestSPISensor(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// At rest, with 1g on the z axis.
+	handle := &fakeSPIHandle{}
+	handle.registers[defaultAddressRegister] = expectedDefaultAddress
+	handle.registers[dataRegister+4] = 64
+	tr := newSPITransport(setupSPI(handle), SPI{Bus: "0", ChipSelect: "0"}, logger)
+	sensor, err := makeMpu6050OnTransport(ctx, logger, testName, &Config{SPI: &SPI{Bus: "0", ChipSelect: "0"}}, tr)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	// The I2C interface is turned off, so the chip stays in SPI mode.
+	test.That(t, handle.registers[userControlRegister]&userControlI2CDisable, test.ShouldNotEqual, 0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accel, err := sensor.LinearAcceleration(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, accel.Z, test.ShouldAlmostEqual, 9.81)
+	})
+}
+
+// fakeMux is a bus with two TCA9548As, at 0x70 and 0x71, and chips both on their channels and
+// wired directly to the bus, all at the same address. Each read answers with the channels selected
+// on each multiplexer, which are the chips that would answer along with a chip wired directly to the
+// bus.
+type fakeMux struct {
+	mu       sync.Mutex
+	channels [2]byte
+}
+
+func (m *fakeMux) bus() buses.I2C {
+	i2c := &inject.I2C{}
+	i2c.OpenHandleFunc = func(addr byte) (buses.I2CHandle, error) {
+		handle := &inject.I2CHandle{}
+		handle.WriteFunc = func(ctx context.Context, tx []byte) error {
+			if (addr != defaultMuxAddress && addr != defaultMuxAddress+1) || len(tx) != 1 {
+				return errors.New("unexpected write")
+			}
+			m.mu.Lock()
+			m.channels[addr-defaultMuxAddress] = tx[0]
+			m.mu.Unlock()
+			// Give another goroutine a chance to switch channels underneath us.
+			time.Sleep(10 * time.Microsecond)
+			return nil
+		}
+		handle.ReadBlockDataFunc = func(ctx context.Context, register byte, numBytes uint8) ([]byte, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return []byte{m.channels[0], m.channels[1]}, nil
+		}
+		handle.CloseFunc = func() error { return nil }
+		return handle, nil
+	}
+	return i2c
+}
+
+func TestI2CMux(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+	mux := &fakeMux{}
+	bus := mux.bus()
+
+	// Every transaction selects the channel first and deselects it afterwards, and every chip on the
+	// bus takes turns, so each read reaches only the chip it is meant for: behind either
+	// multiplexer, or wired directly to the bus.
+	var wg sync.WaitGroup
+	for i := range 5 {
+		i2c := newI2CTransport(bus, "", i2cName, 0x6A, logger)
+		expected := []byte{0, 0}
+		if i < 4 {
+			i2c.mux = &i2cMux{address: byte(defaultMuxAddress + i%2), channel: byte(i)}
+			expected[i%2] = 1 << i
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				data, err := i2c.readBlock(ctx, dataRegister, 1)
+				test.That(t, err, test.ShouldBeNil)
+				test.That(t, data, test.ShouldResemble, expected)
+			}
+		}()
+	}
+	wg.Wait()
+	test.That(t, mux.channels, test.ShouldResemble, [2]byte{0, 0})
+
+	tr := newI2CTransport(bus, "", i2cName, 0x6A, logger)
+	tr.mux = &i2cMux{address: defaultMuxAddress, channel: 5}
+	test.That(t, tr.String(), test.ShouldEqual, "I2C address 106 on bus i2c, multiplexer 112 channel 5")
+	test.That(t, tr.mu, test.ShouldEqual, newI2CTransport(bus, "", i2cName, 0x69, logger).mu)
+	// A bus with the same name on another board is a different bus.
+	other := newI2CTransport(bus, "other-board", i2cName, 0x6A, logger)
+	test.That(t, other.mu, test.ShouldNotEqual, tr.mu)
+	test.That(t, other.busKey(), test.ShouldNotEqual, tr.busKey())
+}
+
+func TestSPIFastSampling(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	// Left over from a configuration that slowed the chip down.
+	handle := &fakeSPIHandle{}
+	handle.registers[defaultAddressRegister] = expectedDefaultAddress
+	handle.registers[sampleRateDivRegister] = 7
+	handle.registers[configRegister] = 3
+	handle.registers[dataRegister+4] = 64
+	conf := &Config{SPI: &SPI{Bus: "1", ChipSelect: "0", SampleRateHz: 8000}}
+	_, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	tr := newSPITransport(setupSPI(handle), *conf.SPI, logger)
+	sensor, err := makeMpu6050OnTransport(ctx, logger, testName, conf, tr)
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+
+	// The chip updates at 8kHz, and the bus is sampled that often.
+	test.That(t, handle.registers[sampleRateDivRegister], test.ShouldEqual, 0)
+	test.That(t, handle.registers[configRegister], test.ShouldEqual, 0)
+	schedulers.mu.Lock()
+	test.That(t, schedulers.byBus[tr.busKey()].period, test.ShouldEqual, 125*time.Microsecond)
+	schedulers.mu.Unlock()
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		accel, err := sensor.LinearAcceleration(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, accel.Z, test.ShouldAlmostEqual, 9.81)
+	})
+
+	// Another chip on the same bus can't be sampled at a different rate.
+	other := &Config{SPI: &SPI{Bus: "1", ChipSelect: "1"}}
+	_, err = makeMpu6050OnTransport(ctx, logger, testName, other, newSPITransport(setupSPI(handle), *other.SPI, logger))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// Only SPI can sample faster than 1kHz, and not with vibration analysis, which slows the chip down.
+	conf.SPI.SampleRateHz = 9000
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	conf.SPI.SampleRateHz = 8000
+	conf.Vibration = &Vibration{}
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "sample_rate_hz")
+}
@@ -0,0 +1,43 @@
+package mpu6050
+
+import (
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// Internally, acceleration is always in m/sec/sec and angular velocity in degrees per second, the
+// units of the movement sensor API. These are the units values can be reported in instead, which
+// are also the suffixes of the flat readings' keys.
+const (
+	accelUnitMPS2 = "mps2"
+	accelUnitG    = "g"
+	gyroUnitDPS   = "dps"
+	gyroUnitRadPS = "radps"
+)
+
+// accelUnitScale returns what to multiply an acceleration in m/sec/sec by to get it in unit.
+func accelUnitScale(unit string) float64 {
+	if unit == accelUnitG {
+		return 1 / gravity
+	}
+	return 1
+}
+
+// gyroUnitScale returns what to multiply an angular velocity in degrees per second by to get it in
+// unit.
+func gyroUnitScale(unit string) float64 {
+	if unit == gyroUnitRadPS {
+		return utils.DegToRad(1)
+	}
+	return 1
+}
+
+// scaleAngularVelocity converts an angular velocity in degrees per second to unit.
+func scaleAngularVelocity(angularVelocity spatialmath.AngularVelocity, unit string) spatialmath.AngularVelocity {
+	scale := gyroUnitScale(unit)
+	return spatialmath.AngularVelocity{
+		X: angularVelocity.X * scale,
+		Y: angularVelocity.Y * scale,
+		Z: angularVelocity.Z * scale,
+	}
+}
@@ -0,0 +1,67 @@
+package mpu6050
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestAngularVelocityConversion(t *testing.T) {
+	counts := []int16{math.MaxInt16, 1 << 14, 131, 1, 0, -1, -(1 << 14), math.MinInt16}
+	for gyroRange := range gyroRanges {
+		for _, count := range counts {
+			data := make([]byte, 6)
+			binary.BigEndian.PutUint16(data[0:2], uint16(count))
+			// Use smaller values of both signs on the other axes.
+			y, z := -int16(int(count)/2), count/4
+			binary.BigEndian.PutUint16(data[2:4], uint16(y))
+			binary.BigEndian.PutUint16(data[4:6], uint16(z))
+
+			// The chip's sensitivity is 32768 counts per full-scale range, in degrees per second.
+			dps := float64(count) * float64(gyroRange) / 32768
+			angularVelocity := toAngularVelocity(data, float64(gyroRange))
+			test.That(t, angularVelocity.X, test.ShouldAlmostEqual, dps)
+			test.That(t, angularVelocity.Y, test.ShouldAlmostEqual, float64(y)*float64(gyroRange)/32768)
+			test.That(t, angularVelocity.Z, test.ShouldAlmostEqual, float64(z)*float64(gyroRange)/32768)
+
+			radians := scaleAngularVelocity(angularVelocity, gyroUnitRadPS)
+			test.That(t, radians.X, test.ShouldAlmostEqual, dps*math.Pi/180)
+			test.That(t, scaleAngularVelocity(angularVelocity, gyroUnitDPS), test.ShouldResemble, angularVelocity)
+		}
+
+		// Full scale is the configured range.
+		data := []byte{0x80, 0, 0, 0, 0, 0}
+		test.That(t, toAngularVelocity(data, float64(gyroRange)).X, test.ShouldEqual, -float64(gyroRange))
+	}
+}
+
+func TestAngularVelocityUnit(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	ctx := context.Background()
+
+	_, err := (&Config{I2cBus: i2cName, AngularVelocityUnit: "rpm"}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "angular_velocity_unit")
+
+	// Half of full scale on X is 250 degrees per second at the 500 dps range.
+	mockData := make([]byte, 16)
+	mockData[8] = 64
+	conf := &Config{I2cBus: i2cName, GyroRange: 500, AngularVelocityUnit: gyroUnitRadPS}
+	sensor, err := makeMpu6050(ctx, logger, testName, conf, setupDependencies(mockData))
+	test.That(t, err, test.ShouldBeNil)
+	defer sensor.Close(ctx)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		angularVelocity, err := sensor.AngularVelocity(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, angularVelocity.X, test.ShouldAlmostEqual, 250*math.Pi/180)
+	})
+	readings, err := sensor.Readings(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, readings["angular_velocity"].(spatialmath.AngularVelocity).X, test.ShouldAlmostEqual, 250*math.Pi/180)
+}
@@ -0,0 +1,54 @@
+package mpu6050
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+const (
+	velocityAccelNoiseDensity   = 400e-6 * gravity // m/sec/sec/sqrt(Hz), from the datasheet
+	velocityResidualAccelStdDev = 0.05             // m/sec/sec of gravity left over from tilt error
+)
+
+// velocityEstimator integrates gravity-free acceleration into a velocity estimate, and resets it
+// to zero whenever the sensor is still. Without those zero-velocity updates, any error in the
+// estimated orientation leaves some gravity in the acceleration and the velocity drifts quickly,
+// so this is only useful over short horizons between stops.
+type velocityEstimator struct {
+	// velocity is in the world frame, in m/sec.
+	velocity r3.Vector
+	// sinceStill is the number of seconds since the last zero-velocity update.
+	sinceStill float64
+}
+
+// update advances the estimate by dt seconds. accel is the sensor-frame acceleration in m/sec/sec,
+// q the current orientation estimate, and still whether the sensor is known to be stationary.
+func (e *velocityEstimator) update(q quat.Number, accel r3.Vector, still bool, dt float64) {
+	if still {
+		e.velocity = r3.Vector{}
+		e.sinceStill = 0
+		return
+	}
+
+	userAccel := rotateToWorld(q, accel).Sub(r3.Vector{Z: gravity})
+	e.velocity = e.velocity.Add(userAccel.Mul(dt))
+	e.sinceStill += dt
+}
+
+// sensorFrameVelocity returns the velocity estimate rotated into the sensor frame, which doesn't
+// depend on the (drifting) yaw estimate.
+func (e *velocityEstimator) sensorFrameVelocity(q quat.Number) r3.Vector {
+	return rotateToSensor(q, e.velocity)
+}
+
+// stdDev returns the estimated standard deviation of each axis of the velocity, in m/sec. White
+// noise in the accelerometer grows as the square root of the time since the last zero-velocity
+// update, and leftover gravity grows linearly with it.
+func (e *velocityEstimator) stdDev() float64 {
+	t := e.sinceStill
+	noise := velocityAccelNoiseDensity * velocityAccelNoiseDensity * t
+	residual := velocityResidualAccelStdDev * t
+	return math.Sqrt(noise + residual*residual)
+}
@@ -0,0 +1,260 @@
+package mpu6050
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+const (
+	// The accelerometer's output rate is 1kHz, which is as fast as the FIFO can be filled with
+	// accelerometer samples.
+	vibrationSampleRate = 1000.0 // Hz
+
+	defaultVibrationWindow = 1024
+	minVibrationWindow     = 64
+	maxVibrationWindow     = 8192
+	defaultVibrationPeaks  = 3
+)
+
+// Vibration configures spectrum analysis of the accelerometer. Samples are read from the chip's
+// FIFO at the accelerometer's full rate of 1kHz, in back-to-back windows, and each window's
+// spectrum is summarized in Readings.
+type Vibration struct {
+	// WindowSamples is the length of each analysis window. Longer windows resolve frequencies more
+	// finely (the resolution is 1000 / WindowSamples Hz) but update less often.
+	WindowSamples int `json:"window_samples,omitempty"`
+	// Axis is "x", "y", or "z" to analyze one axis, or "all" (the default) to combine them.
+	Axis string `json:"axis,omitempty"`
+	// Peaks is how many dominant frequencies to report.
+	Peaks int             `json:"peaks,omitempty"`
+	Bands []VibrationBand `json:"bands,omitempty"`
+}
+
+// VibrationBand is a range of frequencies whose RMS acceleration is reported.
+type VibrationBand struct {
+	Name  string  `json:"name,omitempty"`
+	MinHz float64 `json:"min_hz"`
+	MaxHz float64 `json:"max_hz"`
+}
+
+func (v *Vibration) validate() error {
+	if v.WindowSamples != 0 && (v.WindowSamples < minVibrationWindow || v.WindowSamples > maxVibrationWindow) {
+		return errors.Errorf("vibration.window_samples must be between %d and %d, got %d",
+			minVibrationWindow, maxVibrationWindow, v.WindowSamples)
+	}
+	switch strings.ToLower(v.Axis) {
+	case "", "all", "x", "y", "z":
+	default:
+		return errors.Errorf(`vibration.axis must be "x", "y", "z", or "all", got %q`, v.Axis)
+	}
+	if v.Peaks < 0 {
+		return errors.Errorf("vibration.peaks must not be negative, got %d", v.Peaks)
+	}
+	names := map[string]bool{}
+	for _, band := range v.Bands {
+		if band.MinHz < 0 || band.MaxHz <= band.MinHz || band.MaxHz > vibrationSampleRate/2 {
+			return errors.Errorf("vibration band %q must have 0 <= min_hz < max_hz <= %g", band.name(), vibrationSampleRate/2)
+		}
+		if names[band.name()] {
+			return errors.Errorf("vibration band %q is listed twice", band.name())
+		}
+		names[band.name()] = true
+	}
+	return nil
+}
+
+func (v *Vibration) windowSamples() int {
+	if v.WindowSamples == 0 {
+		return defaultVibrationWindow
+	}
+	return v.WindowSamples
+}
+
+func (v *Vibration) peaks() int {
+	if v.Peaks == 0 {
+		return defaultVibrationPeaks
+	}
+	return v.Peaks
+}
+
+func (band VibrationBand) name() string {
+	if band.Name != "" {
+		return band.Name
+	}
+	return fmt.Sprintf("%g_%g_hz", band.MinHz, band.MaxHz)
+}
+
+// spectralPeak is one of the dominant frequencies in a spectrum, with its amplitude in m/sec/sec.
+type spectralPeak struct {
+	frequency float64
+	amplitude float64
+}
+
+// vibrationResult summarizes one window of accelerometer samples. All accelerations are in
+// m/sec/sec, with the mean (mostly gravity) removed.
+type vibrationResult struct {
+	time time.Time
+	rms  float64
+	// peak is the largest deviation from the mean in the window.
+	peak     float64
+	dominant []spectralPeak
+	// bandRMS holds the RMS acceleration of each configured band, in order.
+	bandRMS []float64
+	// frequencies and amplitudes are the single-sided amplitude spectrum.
+	frequencies []float64
+	amplitudes  []float64
+}
+
+// vibrationAnalyzer turns windows of accelerometer samples into spectra.
+type vibrationAnalyzer struct {
+	conf   Vibration
+	fft    *fourier.FFT
+	window []float64
+	// Sums of the window and its square, used to scale amplitudes and powers.
+	windowSum, windowSquareSum float64
+}
+
+func newVibrationAnalyzer(conf Vibration) *vibrationAnalyzer {
+	n := conf.windowSamples()
+	va := &vibrationAnalyzer{conf: conf, fft: fourier.NewFFT(n), window: make([]float64, n)}
+	// A periodic Hann window keeps energy at one frequency from leaking across the spectrum.
+	for i := range va.window {
+		va.window[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n)))
+		va.windowSum += va.window[i]
+		va.windowSquareSum += va.window[i] * va.window[i]
+	}
+	return va
+}
+
+// analyze computes the spectrum of a full window of samples taken at the given rate.
+func (va *vibrationAnalyzer) analyze(samples []r3.Vector, rate float64, now time.Time) vibrationResult {
+	n := len(va.window)
+	axis := strings.ToLower(va.conf.Axis)
+
+	var mean r3.Vector
+	for _, s := range samples {
+		mean = mean.Add(s)
+	}
+	mean = mean.Mul(1 / float64(n))
+
+	result := vibrationResult{time: now}
+	deviations := make([]r3.Vector, n)
+	for i, s := range samples {
+		deviations[i] = s.Sub(mean)
+		magnitude := deviations[i].Norm()
+		switch axis {
+		case "x":
+			magnitude = math.Abs(deviations[i].X)
+		case "y":
+			magnitude = math.Abs(deviations[i].Y)
+		case "z":
+			magnitude = math.Abs(deviations[i].Z)
+		}
+		result.rms += magnitude * magnitude
+		result.peak = math.Max(result.peak, magnitude)
+	}
+	result.rms = math.Sqrt(result.rms / float64(n))
+
+	// Power in each frequency bin, summed over the analyzed axes.
+	bins := n/2 + 1
+	power := make([]float64, bins)
+	sequence := make([]float64, n)
+	var coefficients []complex128
+	for _, component := range []string{"x", "y", "z"} {
+		if axis != "" && axis != "all" && axis != component {
+			continue
+		}
+		for i, d := range deviations {
+			value := d.X
+			switch component {
+			case "y":
+				value = d.Y
+			case "z":
+				value = d.Z
+			}
+			sequence[i] = value * va.window[i]
+		}
+		coefficients = va.fft.Coefficients(coefficients, sequence)
+		for k := 1; k < bins; k++ {
+			magnitude := cmplx.Abs(coefficients[k])
+			// Every bin but the Nyquist one stands for both a positive and a negative frequency.
+			scale := 2.0
+			if 2*k == n {
+				scale = 1
+			}
+			power[k] += scale * magnitude * magnitude / (float64(n) * va.windowSquareSum)
+		}
+	}
+
+	result.frequencies = make([]float64, bins)
+	result.amplitudes = make([]float64, bins)
+	for k := range bins {
+		result.frequencies[k] = float64(k) * rate / float64(n)
+		// A sine wave's power is half its amplitude squared, before the window's noise bandwidth
+		// spreads it across bins. Undo that spreading so that a peak reads as the sine's amplitude.
+		result.amplitudes[k] = math.Sqrt(2*power[k]*float64(n)*va.windowSquareSum) / va.windowSum
+	}
+
+	result.dominant = dominantPeaks(result.frequencies, result.amplitudes, va.conf.peaks())
+	for _, band := range va.conf.Bands {
+		sum := 0.0
+		for k := 1; k < bins; k++ {
+			if result.frequencies[k] >= band.MinHz && result.frequencies[k] <= band.MaxHz {
+				sum += power[k]
+			}
+		}
+		result.bandRMS = append(result.bandRMS, math.Sqrt(sum))
+	}
+	return result
+}
+
+// dominantPeaks returns the count largest local maxima of the amplitude spectrum, ignoring the DC
+// bin. Each frequency is refined by fitting a parabola through the peak and its neighbors.
+func dominantPeaks(frequencies, amplitudes []float64, count int) []spectralPeak {
+	var peaks []spectralPeak
+	binWidth := frequencies[1] - frequencies[0]
+	for k := 1; k < len(amplitudes); k++ {
+		left := amplitudes[k-1]
+		right := 0.0
+		if k+1 < len(amplitudes) {
+			right = amplitudes[k+1]
+		}
+		if amplitudes[k] <= left || amplitudes[k] < right {
+			continue
+		}
+		offset := 0.0
+		if curvature := left - 2*amplitudes[k] + right; curvature < 0 {
+			offset = 0.5 * (left - right) / curvature
+		}
+		peaks = append(peaks, spectralPeak{frequency: frequencies[k] + offset*binWidth, amplitude: amplitudes[k]})
+	}
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].amplitude > peaks[j].amplitude })
+	if len(peaks) > count {
+		peaks = peaks[:count]
+	}
+	return peaks
+}
+
+// readings flattens the result into Readings entries.
+func (result *vibrationResult) readings(bands []VibrationBand) map[string]interface{} {
+	readings := map[string]interface{}{
+		"vibration_rms_mps2":  result.rms,
+		"vibration_peak_mps2": result.peak,
+	}
+	for i, peak := range result.dominant {
+		readings[fmt.Sprintf("vibration_peak_%d_hz", i+1)] = peak.frequency
+		readings[fmt.Sprintf("vibration_peak_%d_mps2", i+1)] = peak.amplitude
+	}
+	for i, band := range bands {
+		readings["vibration_band_"+band.name()+"_rms_mps2"] = result.bandRMS[i]
+	}
+	return readings
+}
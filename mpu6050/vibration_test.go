@@ -0,0 +1,86 @@
+package mpu6050
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestVibrationAnalysis(t *testing.T) {
+	conf := Vibration{
+		WindowSamples: 1000,
+		Bands: []VibrationBand{
+			{Name: "motor", MinHz: 100, MaxHz: 140},
+			{MinHz: 240, MaxHz: 260},
+			{Name: "quiet", MinHz: 400, MaxHz: 450},
+		},
+	}
+	test.That(t, conf.validate(), test.ShouldBeNil)
+	analyzer := newVibrationAnalyzer(conf)
+
+	// Gravity on Z, a 2 m/sec/sec tone at 120Hz on X, and a 0.5 m/sec/sec tone at 250Hz on Y. Both
+	// frequencies fall exactly on a bin, since the window is one second long.
+	samples := make([]r3.Vector, 1000)
+	for i := range samples {
+		seconds := float64(i) / vibrationSampleRate
+		samples[i] = r3.Vector{
+			X: 2 * math.Sin(2*math.Pi*120*seconds),
+			Y: 0.5 * math.Sin(2*math.Pi*250*seconds),
+			Z: gravity,
+		}
+	}
+	result := analyzer.analyze(samples, vibrationSampleRate, time.Now())
+
+	test.That(t, result.rms, test.ShouldAlmostEqual, math.Sqrt(2*2/2.0+0.5*0.5/2), 1e-6)
+	test.That(t, result.peak, test.ShouldBeLessThanOrEqualTo, 2.5)
+	test.That(t, result.peak, test.ShouldBeGreaterThan, 2)
+
+	test.That(t, len(result.dominant), test.ShouldEqual, defaultVibrationPeaks)
+	test.That(t, result.dominant[0].frequency, test.ShouldAlmostEqual, 120, 1e-6)
+	test.That(t, result.dominant[0].amplitude, test.ShouldAlmostEqual, 2, 1e-6)
+	test.That(t, result.dominant[1].frequency, test.ShouldAlmostEqual, 250, 1e-6)
+	test.That(t, result.dominant[1].amplitude, test.ShouldAlmostEqual, 0.5, 1e-6)
+
+	test.That(t, result.bandRMS[0], test.ShouldAlmostEqual, 2/math.Sqrt2, 1e-6)
+	test.That(t, result.bandRMS[1], test.ShouldAlmostEqual, 0.5/math.Sqrt2, 1e-6)
+	test.That(t, result.bandRMS[2], test.ShouldAlmostEqual, 0, 1e-6)
+
+	readings := result.readings(conf.Bands)
+	test.That(t, readings["vibration_peak_1_hz"], test.ShouldAlmostEqual, 120, 1e-6)
+	test.That(t, readings, test.ShouldContainKey, "vibration_band_motor_rms_mps2")
+	test.That(t, readings, test.ShouldContainKey, "vibration_band_240_260_hz_rms_mps2")
+
+	// Analyzing only Y sees only the 250Hz tone.
+	conf.Axis = "y"
+	result = newVibrationAnalyzer(conf).analyze(samples, vibrationSampleRate, time.Now())
+	test.That(t, result.dominant[0].frequency, test.ShouldAlmostEqual, 250, 1e-6)
+	test.That(t, result.rms, test.ShouldAlmostEqual, 0.5/math.Sqrt2, 1e-6)
+	test.That(t, result.bandRMS[0], test.ShouldAlmostEqual, 0, 1e-6)
+}
+
+func TestVibrationOffBinFrequency(t *testing.T) {
+	analyzer := newVibrationAnalyzer(Vibration{WindowSamples: 512})
+	samples := make([]r3.Vector, 512)
+	for i := range samples {
+		samples[i] = r3.Vector{Z: math.Sin(2 * math.Pi * 87.3 * float64(i) / vibrationSampleRate)}
+	}
+	result := analyzer.analyze(samples, vibrationSampleRate, time.Now())
+	// The bins are nearly 2Hz apart, but interpolation gets much closer than that.
+	test.That(t, result.dominant[0].frequency, test.ShouldAlmostEqual, 87.3, 0.3)
+	// Between bins, a Hann window loses up to 15% of the amplitude.
+	test.That(t, result.dominant[0].amplitude, test.ShouldBeBetween, 0.84, 1.0)
+}
+
+func TestVibrationValidation(t *testing.T) {
+	test.That(t, (&Vibration{}).validate(), test.ShouldBeNil)
+	test.That(t, (&Vibration{WindowSamples: 10}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Vibration{Axis: "w"}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Vibration{Peaks: -1}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Vibration{Bands: []VibrationBand{{MinHz: 10, MaxHz: 5}}}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Vibration{Bands: []VibrationBand{{MinHz: 10, MaxHz: 600}}}).validate(), test.ShouldNotBeNil)
+	test.That(t, (&Vibration{Bands: []VibrationBand{{Name: "a", MinHz: 1, MaxHz: 2}, {Name: "a", MinHz: 3, MaxHz: 4}}}).validate(),
+		test.ShouldNotBeNil)
+}